@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/emanuelef/github-repo-activity-stats/repostats"
@@ -13,7 +14,11 @@ import (
 	"golang.org/x/oauth2"
 )
 
-const ghRepo = "kubernetes/kubernetes"
+const (
+	ghRepo     = "kubernetes/kubernetes"
+	csvFile    = "all-stars-k8s.csv"
+	cursorFile = "all-stars-k8s.cursor"
+)
 
 func main() {
 	ctx := context.Background()
@@ -22,25 +27,58 @@ func main() {
 	)
 
 	oauthClient := oauth2.NewClient(context.Background(), tokenSource)
-	clientGQL := repostats.NewClientGQL(oauthClient)
 	currentTime := time.Now()
+	// a fixed clock makes the cursor of a first run point at the last star counted
+	clientGQL := repostats.NewClientGQL(oauthClient, repostats.WithClock(repostats.FixedClock(currentTime)))
 	result, _ := clientGQL.GetAllStats(ctx, ghRepo)
 	fmt.Println(result)
 
+	// Resume from the previous run when both the history and the cursor are available,
+	// otherwise fetch the whole history both ways, faster, and the cursor to resume from.
+	previousStars, err := repostats.ReadStarsHistoryCSV(csvFile)
+	if err != nil {
+		log.Printf("No previous history: %v\n", err)
+	}
+
+	lastCursor := ""
+	if data, err := os.ReadFile(cursorFile); err == nil {
+		lastCursor = strings.TrimSpace(string(data))
+	}
+
 	updateChannel := make(chan int)
 
 	var allStars []stats.StarsPerDay
+	var newCursor string
+
+	done := make(chan struct{})
 
 	go func() {
-		allStars, _ = clientGQL.GetAllStarsHistoryTwoWays(ctx, ghRepo, updateChannel)
-		// allStars, _ = clientGQL.GetAllStarsHistory(ctx, ghRepo, result.CreatedAt, updateChannel)
+		defer close(done)
+		if len(previousStars) == 0 || lastCursor == "" {
+			allStars, err = clientGQL.GetAllStarsHistoryTwoWays(ctx, ghRepo, updateChannel)
+			if err == nil {
+				newCursor, err = clientGQL.GetLastStargazerCursor(ctx, ghRepo)
+			}
+			return
+		}
+		allStars, newCursor, err = clientGQL.GetAllStarsHistoryIncremental(ctx, ghRepo, previousStars, lastCursor, updateChannel)
 	}()
 
 	for progress := range updateChannel {
 		fmt.Printf("Progress: %d\n", progress)
 	}
 
-	repostats.WriteStarsHistoryCSV("all-stars-k8s.csv", allStars)
+	<-done
+
+	if err != nil {
+		log.Fatalf("Error getting stars history %v", err)
+	}
+
+	repostats.WriteStarsHistoryCSV(csvFile, allStars)
+
+	if err := os.WriteFile(cursorFile, []byte(newCursor+"\n"), 0o644); err != nil {
+		log.Fatal(err)
+	}
 
 	elapsed := time.Since(currentTime)
 	log.Printf("Took %s\n", elapsed)
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Cache stores raw responses keyed by an opaque string.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key, false if missing or expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value for key, a ttl <= 0 means the entry never expires.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// Key hashes the given parts into a fixed length key usable by any Cache.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Namespace returns a view of c whose keys don't collide with the ones of other namespaces,
+// to share c between users, like one namespace per token.
+func Namespace(c Cache, namespace string) Cache {
+	return &namespaced{cache: c, namespace: namespace}
+}
+
+type namespaced struct {
+	cache     Cache
+	namespace string
+}
+
+func (c *namespaced) Get(key string) ([]byte, bool) {
+	return c.cache.Get(Key(c.namespace, key))
+}
+
+func (c *namespaced) Set(key string, value []byte, ttl time.Duration) {
+	c.cache.Set(Key(c.namespace, key), value, ttl)
+}
+
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func expired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && time.Now().After(expiresAt)
+}
+
+type bypassKey struct{}
+
+// Bypass returns a context whose requests skip the cache, always reaching the server
+// without storing their responses, for data that must be fresh like rate limits.
+// It applies to Transport and to the GraphQL queries of repostats.ClientGQL.
+func Bypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Bypassed reports whether ctx, or one of its parents, was returned by Bypass.
+func Bypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(bypassKey{}).(bool)
+	return bypassed
+}
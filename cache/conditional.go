@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// ConditionalTransport is an http.RoundTripper revalidating stored GET responses.
+// Responses carrying an ETag or a Last-Modified header are stored, the following identical
+// requests send them back as If-None-Match and If-Modified-Since, and a 304 Not Modified
+// answer is turned into the stored response. GitHub doesn't count 304 responses against
+// the REST rate limit.
+//
+// Entries never expire, as they are checked with the server on every request.
+type ConditionalTransport struct {
+	Cache Cache
+	Base  http.RoundTripper
+}
+
+// NewConditionalTransport wraps base so that GET requests are revalidated against c.
+// If base is nil, http.DefaultTransport is used.
+func NewConditionalTransport(c Cache, base http.RoundTripper) *ConditionalTransport {
+	return &ConditionalTransport{Cache: c, Base: base}
+}
+
+func (t *ConditionalTransport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *ConditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// requests already conditional are left to the caller
+	if req.Method != http.MethodGet || t.Cache == nil ||
+		req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return t.base().RoundTrip(req)
+	}
+
+	// the stored response is shared by all tokens, as it is only served after the
+	// server confirmed it is unchanged for the token of the request
+	key := Key("conditional", RequestKey(req))
+
+	var stored *http.Response
+	var storedBody []byte
+	if data, ok := t.Cache.Get(key); ok {
+		if resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req); err == nil {
+			if body, err := io.ReadAll(resp.Body); err == nil {
+				stored, storedBody = resp, body
+			}
+			resp.Body.Close()
+		}
+	}
+
+	outReq := req
+	if stored != nil {
+		outReq = req.Clone(req.Context())
+		if etag := stored.Header.Get("ETag"); etag != "" {
+			outReq.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := stored.Header.Get("Last-Modified"); lastModified != "" {
+			outReq.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.base().RoundTrip(outReq)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && stored != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		// fresh headers, like the rate limit ones, replace the stored ones
+		for name, values := range resp.Header {
+			switch name {
+			case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+				continue
+			}
+			stored.Header[name] = values
+		}
+		stored.Body = io.NopCloser(bytes.NewReader(storedBody))
+		stored.ContentLength = int64(len(storedBody))
+		stored.Request = req
+
+		return stored, nil
+	}
+
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return resp, nil
+	}
+
+	t.Cache.Set(key, data, 0)
+
+	return resp, nil
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type fileEntry struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	Value     []byte    `json:"value"`
+}
+
+// File is a Cache persisting each entry as a file in a directory,
+// so responses survive across runs of the same program.
+type File struct {
+	dir string
+}
+
+// NewFile creates a file backed cache in dir, creating the directory if needed.
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir}, nil
+}
+
+func (c *File) path(key string) string {
+	return filepath.Join(c.dir, Key(key)+".json")
+}
+
+func (c *File) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+
+	if expired(entry.ExpiresAt) {
+		os.Remove(c.path(key))
+		return nil, false
+	}
+
+	return entry.Value, true
+}
+
+func (c *File) Set(key string, value []byte, ttl time.Duration) {
+	data, err := json.Marshal(fileEntry{ExpiresAt: expiresAt(ttl), Value: value})
+	if err != nil {
+		return
+	}
+
+	// write to a temporary file first so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
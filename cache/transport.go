@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httputil"
+	"time"
+)
+
+// Transport is an http.RoundTripper serving successful GET responses from a Cache.
+type Transport struct {
+	Cache Cache
+	TTL   time.Duration
+	Base  http.RoundTripper
+}
+
+// NewTransport wraps base so that successful GET responses are stored in c for ttl.
+// If base is nil, http.DefaultTransport is used.
+func NewTransport(c Cache, ttl time.Duration, base http.RoundTripper) *Transport {
+	return &Transport{Cache: c, TTL: ttl, Base: base}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+// RequestKey identifies a GET request, the Accept header is part of the key
+// as GitHub returns different representations depending on it.
+// Credentials are not, they are usually added by transports below the cache: a Cache
+// shared between transports authenticating with different tokens must be split with Namespace.
+func RequestKey(req *http.Request) string {
+	return Key(req.Method, req.URL.String(), req.Header.Get("Accept"))
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || t.Cache == nil || Bypassed(req.Context()) {
+		return t.base().RoundTrip(req)
+	}
+
+	key := RequestKey(req)
+
+	if data, ok := t.Cache.Get(key); ok {
+		if resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req); err == nil {
+			return resp, nil
+		}
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return resp, nil
+	}
+
+	t.Cache.Set(key, data, t.TTL)
+
+	return resp, nil
+}
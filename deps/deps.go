@@ -2,6 +2,7 @@ package deps
 
 import (
 	"context"
+	"log/slog"
 	"strings"
 
 	"github.com/emanuelef/github-repo-activity-stats/stats"
@@ -17,17 +18,65 @@ type DepsFetcher interface {
 	GetDepsList(ctx context.Context, restyClient *resty.Client, ghRepo string, result *stats.RepoStats) error
 }
 
-func CreateFetcher(lang string) DepsFetcher {
+type config struct {
+	logger *slog.Logger
+	rawURL string
+}
+
+// Option configures the fetchers returned by CreateFetcher.
+type Option func(*config)
+
+// WithLogger logs what the fetchers find to logger, by default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithRawURL fetches the dependency files from rawURL instead of raw.githubusercontent.com,
+// e.g. for GitHub Enterprise Server.
+func WithRawURL(rawURL string) Option {
+	return func(c *config) {
+		c.rawURL = strings.TrimSuffix(rawURL, "/")
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// log is safe to call on fetchers created without CreateFetcher.
+func (c *config) log() *slog.Logger {
+	if c == nil || c.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return c.logger
+}
+
+func (c *config) raw() string {
+	if c == nil || c.rawURL == "" {
+		return rawGHUrl
+	}
+	return c.rawURL
+}
+
+func CreateFetcher(lang string, opts ...Option) DepsFetcher {
+	cfg := newConfig(opts)
+
 	switch strings.ToLower(lang) {
 	case "go":
-		return GoDepsFetcher{}
+		return GoDepsFetcher{cfg: cfg}
 	case "rust":
-		return RustDepsFetcher{}
+		return RustDepsFetcher{cfg: cfg}
 	case "javascript":
-		return JavascriptDepsFetcher{}
+		return JavascriptDepsFetcher{cfg: cfg}
 	case "python":
-		return PythonDepsFetcher{}
+		return PythonDepsFetcher{cfg: cfg}
 	default:
-		return GoDepsFetcher{}
+		return GoDepsFetcher{cfg: cfg}
 	}
 }
@@ -9,14 +9,16 @@ import (
 	"golang.org/x/mod/modfile"
 )
 
-type GoDepsFetcher struct{}
+type GoDepsFetcher struct {
+	cfg *config
+}
 
 func (gdf GoDepsFetcher) Create() GoDepsFetcher {
 	return GoDepsFetcher{}
 }
 
 func (gdf GoDepsFetcher) GetDepsList(ctx context.Context, restyClient *resty.Client, ghRepo string, result *stats.RepoStats) error {
-	goModUrl := fmt.Sprintf("%s/%s/%s/go.mod", rawGHUrl, ghRepo, result.DefaultBranch)
+	goModUrl := fmt.Sprintf("%s/%s/%s/go.mod", gdf.cfg.raw(), ghRepo, result.DefaultBranch)
 
 	restyReq := restyClient.R()
 	restyReq.SetContext(ctx)
@@ -9,7 +9,9 @@ import (
 	"github.com/go-resty/resty/v2"
 )
 
-type JavascriptDepsFetcher struct{}
+type JavascriptDepsFetcher struct {
+	cfg *config
+}
 
 type PackageInfo struct {
 	Name            string            `json:"name"`
@@ -22,7 +24,7 @@ func (gdf JavascriptDepsFetcher) Create() JavascriptDepsFetcher {
 }
 
 func (gdf JavascriptDepsFetcher) GetDepsList(ctx context.Context, restyClient *resty.Client, ghRepo string, result *stats.RepoStats) error {
-	packageJsonTomUrl := fmt.Sprintf("%s/%s/%s/package.json", rawGHUrl, ghRepo, result.DefaultBranch)
+	packageJsonTomUrl := fmt.Sprintf("%s/%s/%s/package.json", gdf.cfg.raw(), ghRepo, result.DefaultBranch)
 
 	restyReq := restyClient.R()
 	restyReq.SetContext(ctx)
@@ -33,22 +35,23 @@ func (gdf JavascriptDepsFetcher) GetDepsList(ctx context.Context, restyClient *r
 		var pkgInfo PackageInfo
 		err := json.Unmarshal(resp.Body(), &pkgInfo)
 		if err != nil {
-			fmt.Println("Error:", err)
+			gdf.cfg.log().Debug("parsing package.json failed", "repo", ghRepo, "operation", "GetDepsList", "error", err)
 			return err
 		}
 
 		var directDeps []string
 
-		fmt.Println("Dependencies:")
 		for dep := range pkgInfo.Dependencies {
 			directDeps = append(directDeps, dep)
 		}
 
-		fmt.Println("\nDev Dependencies:")
 		for dep := range pkgInfo.DevDependencies {
 			directDeps = append(directDeps, dep)
 		}
 
+		gdf.cfg.log().Debug("dependencies", "repo", ghRepo, "operation", "GetDepsList",
+			"dependencies", len(pkgInfo.Dependencies), "devDependencies", len(pkgInfo.DevDependencies))
+
 		result.DirectDeps = directDeps
 	}
 
@@ -13,14 +13,16 @@ import (
 	"github.com/pelletier/go-toml"
 )
 
-type PythonDepsFetcher struct{}
+type PythonDepsFetcher struct {
+	cfg *config
+}
 
 func (gdf PythonDepsFetcher) Create() PythonDepsFetcher {
 	return PythonDepsFetcher{}
 }
 
 func (gdf PythonDepsFetcher) GetDepsList(ctx context.Context, restyClient *resty.Client, ghRepo string, result *stats.RepoStats) error {
-	requirementsUrl := fmt.Sprintf("%s/%s/%s/requirements.txt", rawGHUrl, ghRepo, result.DefaultBranch)
+	requirementsUrl := fmt.Sprintf("%s/%s/%s/requirements.txt", gdf.cfg.raw(), ghRepo, result.DefaultBranch)
 
 	restyReq := restyClient.R()
 	restyReq.SetContext(ctx)
@@ -51,7 +53,7 @@ func (gdf PythonDepsFetcher) GetDepsList(ctx context.Context, restyClient *resty
 		}
 	}
 
-	poetryUrl := fmt.Sprintf("%s/%s/%s/pyproject.toml", rawGHUrl, ghRepo, result.DefaultBranch)
+	poetryUrl := fmt.Sprintf("%s/%s/%s/pyproject.toml", gdf.cfg.raw(), ghRepo, result.DefaultBranch)
 
 	resp, err = restyReq.Get(poetryUrl)
 
@@ -68,7 +70,7 @@ func (gdf PythonDepsFetcher) GetDepsList(ctx context.Context, restyClient *resty
 		}
 	}
 
-	setupUrl := fmt.Sprintf("%s/%s/%s/setup.py", rawGHUrl, ghRepo, result.DefaultBranch)
+	setupUrl := fmt.Sprintf("%s/%s/%s/setup.py", gdf.cfg.raw(), ghRepo, result.DefaultBranch)
 
 	resp, err = restyReq.Get(setupUrl)
 
@@ -94,7 +96,7 @@ func (gdf PythonDepsFetcher) GetDepsList(ctx context.Context, restyClient *resty
 		}
 	}
 
-	pipfileUrl := fmt.Sprintf("%s/%s/%s/Pipfile", rawGHUrl, ghRepo, result.DefaultBranch)
+	pipfileUrl := fmt.Sprintf("%s/%s/%s/Pipfile", gdf.cfg.raw(), ghRepo, result.DefaultBranch)
 
 	resp, err = restyReq.Get(pipfileUrl)
 
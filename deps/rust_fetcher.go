@@ -9,14 +9,16 @@ import (
 	"github.com/pelletier/go-toml"
 )
 
-type RustDepsFetcher struct{}
+type RustDepsFetcher struct {
+	cfg *config
+}
 
 func (gdf RustDepsFetcher) Create() RustDepsFetcher {
 	return RustDepsFetcher{}
 }
 
 func (gdf RustDepsFetcher) GetDepsList(ctx context.Context, restyClient *resty.Client, ghRepo string, result *stats.RepoStats) error {
-	cargoTomUrl := fmt.Sprintf("%s/%s/%s/Cargo.toml", rawGHUrl, ghRepo, result.DefaultBranch)
+	cargoTomUrl := fmt.Sprintf("%s/%s/%s/Cargo.toml", gdf.cfg.raw(), ghRepo, result.DefaultBranch)
 
 	restyReq := restyClient.R()
 	restyReq.SetContext(ctx)
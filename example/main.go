@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/emanuelef/github-repo-activity-stats/cache"
 	"github.com/emanuelef/github-repo-activity-stats/repostats"
 	"github.com/emanuelef/github-repo-activity-stats/stats"
 	_ "github.com/joho/godotenv/autoload"
@@ -54,7 +55,8 @@ func main() {
 	)
 
 	oauthClient := oauth2.NewClient(context.Background(), tokenSource)
-	client := repostats.NewClientGQL(oauthClient)
+	// repos share a lot of metadata lookups, keep them around for the whole batch
+	client := repostats.NewClientGQL(oauthClient, repostats.WithCache(cache.NewLRU(10000), time.Hour))
 
 	file, err := os.Open("repos.txt")
 	if err != nil {
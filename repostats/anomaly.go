@@ -0,0 +1,190 @@
+package repostats
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+const (
+	// anomalyBaselineDays is the number of previous days averaged into the baseline.
+	anomalyBaselineDays = 28
+	// anomalyMinStars is the number of stars under which a day is never a spike.
+	anomalyMinStars = 10
+	// anomalySpikeRatio is the ratio over the baseline for which the spike component reaches 1.
+	anomalySpikeRatio = 20
+	// anomalyMinProfiles is the number of stargazer profiles needed to judge a day by its accounts.
+	anomalyMinProfiles = 5
+	// anomalyClusterGap is the gap under which two consecutive stars are clustered.
+	anomalyClusterGap = time.Minute
+	// anomalySignalThreshold is the value from which a signal makes a day part of a suspicious window.
+	anomalySignalThreshold = 0.5
+)
+
+// starAnomalySignals are the components of the score of a day, from 0 to 1.
+type starAnomalySignals struct {
+	spike       float64
+	lowQuality  float64
+	clustered   float64
+	hasProfiles bool
+}
+
+func (s starAnomalySignals) score() float64 {
+	return 0.5*s.spike + 0.25*s.lowQuality + 0.25*s.clustered
+}
+
+// reasons returns the signals reaching anomalySignalThreshold.
+func (s starAnomalySignals) reasons() []string {
+	reasons := []string{}
+	if s.spike >= anomalySignalThreshold {
+		reasons = append(reasons, "spike")
+	}
+	if s.hasProfiles && s.lowQuality >= anomalySignalThreshold {
+		reasons = append(reasons, "low-quality accounts")
+	}
+	if s.hasProfiles && s.clustered >= anomalySignalThreshold {
+		reasons = append(reasons, "clustered timestamps")
+	}
+	return reasons
+}
+
+// DetectStarAnomalies scores each day of a stars timeline, as returned by GetAllStarsHistoryTwoWays,
+// for signs of bought stars, and groups the consecutive days with at least one signal in windows.
+//
+// The signals are a spike, the stars of the day being far above the average of the previous 4 weeks,
+// and, with the profiles of at least 5 stargazers of the day as returned by GetStargazerProfiles,
+// a high share of throwaway accounts, without followers and repositories or created less than 30 days
+// before starring, and a high share of stars given less than a minute after the previous one.
+// Any of them is enough for a window, listed in its reasons. The score weighs the spike for half
+// and the profile signals for a quarter each, so a spike alone, like the one of a launch,
+// is reported with a low confidence and the windows with bought accounts stand out.
+func DetectStarAnomalies(timeline []stats.StarsPerDay, profiles []stats.StargazerProfile) stats.StarAnomalyReport {
+	report := stats.StarAnomalyReport{
+		Days:    make([]stats.StarAnomalyDay, 0, len(timeline)),
+		Windows: []stats.StarAnomalyWindow{},
+	}
+
+	// profiles go to the days in the location of the timeline
+	loc := time.UTC
+	if len(timeline) > 0 {
+		loc = time.Time(timeline[0].Day).Location()
+	}
+	dayOf := func(t time.Time) time.Time {
+		year, month, day := t.In(loc).Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+
+	profilesPerDay := map[time.Time][]stats.StargazerProfile{}
+	for _, profile := range profiles {
+		day := dayOf(profile.StarredAt)
+		profilesPerDay[day] = append(profilesPerDay[day], profile)
+	}
+
+	signals := make([]starAnomalySignals, len(timeline))
+
+	windowSum := 0
+	for i, day := range timeline {
+		baseline := 0.0
+		if i > 0 {
+			baseline = float64(windowSum) / float64(min(i, anomalyBaselineDays))
+		}
+
+		windowSum += day.Stars
+		if i >= anomalyBaselineDays {
+			windowSum -= timeline[i-anomalyBaselineDays].Stars
+		}
+
+		// the first day has no baseline to spike over
+		if i > 0 && day.Stars >= anomalyMinStars {
+			ratio := float64(day.Stars) / (baseline + 1)
+			signals[i].spike = math.Max(0, math.Min(1, math.Log(ratio)/math.Log(anomalySpikeRatio)))
+		}
+
+		dayProfiles := profilesPerDay[dayOf(time.Time(day.Day))]
+		if len(dayProfiles) >= anomalyMinProfiles {
+			signals[i].hasProfiles = true
+			signals[i].lowQuality = lowQualityShare(dayProfiles)
+			signals[i].clustered = clusteredShare(dayProfiles)
+		}
+
+		report.Days = append(report.Days, stats.StarAnomalyDay{
+			Day:      day.Day,
+			Stars:    day.Stars,
+			Baseline: baseline,
+			Score:    signals[i].score(),
+		})
+	}
+
+	var window *stats.StarAnomalyWindow
+	reasons := map[string]struct{}{}
+
+	closeWindow := func() {
+		if window == nil {
+			return
+		}
+		for _, reason := range []string{"spike", "low-quality accounts", "clustered timestamps"} {
+			if _, ok := reasons[reason]; ok {
+				window.Reasons = append(window.Reasons, reason)
+			}
+		}
+		report.Windows = append(report.Windows, *window)
+		window = nil
+		clear(reasons)
+	}
+
+	for i, day := range report.Days {
+		dayReasons := signals[i].reasons()
+		if len(dayReasons) == 0 {
+			closeWindow()
+			continue
+		}
+
+		if window == nil {
+			window = &stats.StarAnomalyWindow{Start: day.Day, Reasons: []string{}}
+		}
+
+		window.End = day.Day
+		window.Stars += day.Stars
+		window.ExcessStars += max(0, day.Stars-int(math.Round(day.Baseline)))
+		window.Confidence = math.Max(window.Confidence, day.Score)
+
+		for _, reason := range dayReasons {
+			reasons[reason] = struct{}{}
+		}
+	}
+	closeWindow()
+
+	return report
+}
+
+// lowQualityShare returns the share of stargazers with an empty or brand-new account.
+func lowQualityShare(profiles []stats.StargazerProfile) float64 {
+	lowQuality := 0
+	for _, profile := range profiles {
+		empty := profile.Followers == 0 && profile.Repositories == 0
+		brandNew := !profile.CreatedAt.IsZero() && profile.StarredAt.Sub(profile.CreatedAt) < newAccountAge
+		if empty || brandNew {
+			lowQuality++
+		}
+	}
+	return float64(lowQuality) / float64(len(profiles))
+}
+
+// clusteredShare returns the share of stars given shortly after the previous one.
+func clusteredShare(profiles []stats.StargazerProfile) float64 {
+	starredAt := make([]time.Time, 0, len(profiles))
+	for _, profile := range profiles {
+		starredAt = append(starredAt, profile.StarredAt)
+	}
+	slices.SortFunc(starredAt, time.Time.Compare)
+
+	clustered := 0
+	for i := 1; i < len(starredAt); i++ {
+		if starredAt[i].Sub(starredAt[i-1]) < anomalyClusterGap {
+			clustered++
+		}
+	}
+	return float64(clustered) / float64(len(starredAt)-1)
+}
@@ -0,0 +1,169 @@
+package repostats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+func TestDetectStarAnomalies(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return start.AddDate(0, 0, i) }
+
+	// timeline returns 60 days of base stars, with the given days overridden
+	timeline := func(base int, stars map[int]int) []stats.StarsPerDay {
+		days := make([]stats.StarsPerDay, 60)
+		total := 0
+		for i := range days {
+			n, ok := stars[i]
+			if !ok {
+				n = base
+			}
+			total += n
+			days[i] = stats.StarsPerDay{Day: stats.JSONDay(day(i)), Stars: n, TotalStars: total}
+		}
+		return days
+	}
+
+	// bought returns empty accounts starring a few seconds apart on a day
+	bought := func(i, n int) []stats.StargazerProfile {
+		profiles := make([]stats.StargazerProfile, n)
+		for j := range profiles {
+			starredAt := day(i).Add(10*time.Hour + time.Duration(j)*5*time.Second)
+			profiles[j] = stats.StargazerProfile{CreatedAt: starredAt.AddDate(0, 0, -2), StarredAt: starredAt}
+		}
+		return profiles
+	}
+
+	// genuine returns established accounts starring hours apart on a day
+	genuine := func(i, n int) []stats.StargazerProfile {
+		profiles := make([]stats.StargazerProfile, n)
+		for j := range profiles {
+			profiles[j] = stats.StargazerProfile{
+				Followers:    10,
+				Repositories: 5,
+				CreatedAt:    day(i).AddDate(-3, 0, 0),
+				StarredAt:    day(i).Add(time.Duration(j) * time.Hour),
+			}
+		}
+		return profiles
+	}
+
+	// the spike component of the confidence, 12 stars over a baseline of 2 stay under the threshold
+	moderateSpike := math.Log(12.0/3) / math.Log(anomalySpikeRatio)
+
+	tests := []struct {
+		name     string
+		timeline []stats.StarsPerDay
+		profiles []stats.StargazerProfile
+		want     []stats.StarAnomalyWindow
+	}{
+		{
+			name:     "flat",
+			timeline: timeline(2, nil),
+			want:     []stats.StarAnomalyWindow{},
+		},
+		{
+			name:     "spike without profiles",
+			timeline: timeline(2, map[int]int{40: 60, 41: 90}),
+			want: []stats.StarAnomalyWindow{
+				{Start: stats.JSONDay(day(40)), End: stats.JSONDay(day(41)), Stars: 150, ExcessStars: 58 + 86, Confidence: 0.5, Reasons: []string{"spike"}},
+			},
+		},
+		{
+			name:     "spike of genuine stargazers",
+			timeline: timeline(2, map[int]int{40: 60}),
+			profiles: genuine(40, 10),
+			want: []stats.StarAnomalyWindow{
+				{Start: stats.JSONDay(day(40)), End: stats.JSONDay(day(40)), Stars: 60, ExcessStars: 58, Confidence: 0.5, Reasons: []string{"spike"}},
+			},
+		},
+		{
+			name:     "spike of bought stargazers",
+			timeline: timeline(2, map[int]int{40: 60}),
+			profiles: bought(40, 10),
+			want: []stats.StarAnomalyWindow{
+				{Start: stats.JSONDay(day(40)), End: stats.JSONDay(day(40)), Stars: 60, ExcessStars: 58, Confidence: 1, Reasons: []string{"spike", "low-quality accounts", "clustered timestamps"}},
+			},
+		},
+		{
+			name:     "bought stargazers under the spike threshold",
+			timeline: timeline(2, map[int]int{40: 12}),
+			profiles: bought(40, 6),
+			want: []stats.StarAnomalyWindow{
+				{Start: stats.JSONDay(day(40)), End: stats.JSONDay(day(40)), Stars: 12, ExcessStars: 10, Confidence: 0.5*moderateSpike + 0.5, Reasons: []string{"low-quality accounts", "clustered timestamps"}},
+			},
+		},
+		{
+			name:     "too few profiles",
+			timeline: timeline(2, map[int]int{40: 12}),
+			profiles: bought(40, anomalyMinProfiles-1),
+			want:     []stats.StarAnomalyWindow{},
+		},
+		{
+			name:     "too few stars for a spike",
+			timeline: timeline(0, map[int]int{40: anomalyMinStars - 1}),
+			want:     []stats.StarAnomalyWindow{},
+		},
+		{
+			name:     "spike absorbed by the baseline",
+			timeline: timeline(40, map[int]int{40: 60}),
+			want:     []stats.StarAnomalyWindow{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := DetectStarAnomalies(tt.timeline, tt.profiles)
+
+			if len(report.Days) != len(tt.timeline) {
+				t.Fatalf("got %d days, want %d", len(report.Days), len(tt.timeline))
+			}
+
+			got := report.Windows
+			for i := range got {
+				if i < len(tt.want) && math.Abs(got[i].Confidence-tt.want[i].Confidence) < 1e-9 {
+					got[i].Confidence = tt.want[i].Confidence
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("windows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectStarAnomaliesBaseline(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 10 stars a day for 30 days, then 40 a day
+	var timeline []stats.StarsPerDay
+	for i := range 60 {
+		stars := 10
+		if i >= 30 {
+			stars = 40
+		}
+		timeline = append(timeline, stats.StarsPerDay{Day: stats.JSONDay(start.AddDate(0, 0, i)), Stars: stars})
+	}
+
+	report := DetectStarAnomalies(timeline, nil)
+
+	tests := []struct {
+		day      int
+		baseline float64
+	}{
+		{day: 0, baseline: 0},
+		{day: 1, baseline: 10},
+		{day: 30, baseline: 10},
+		{day: 44, baseline: (14*10 + 14*40) / 28.0},
+		{day: 59, baseline: 40},
+	}
+	for _, tt := range tests {
+		if got := report.Days[tt.day].Baseline; math.Abs(got-tt.baseline) > 1e-9 {
+			t.Errorf("day %d: Baseline = %v, want %v", tt.day, got, tt.baseline)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package repostats
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+	"github.com/shurcooL/githubv4"
+)
+
+// newAccountAge is the age under which the account of a stargazer is counted as brand-new.
+const newAccountAge = 30 * 24 * time.Hour
+
+// accountAgeBuckets are the lower bounds, in days, of the account age histogram.
+var accountAgeBuckets = []stats.AccountAgeBucket{
+	{Label: "< 1 week", MinDays: 0},
+	{Label: "1 week - 1 month", MinDays: 7},
+	{Label: "1 - 6 months", MinDays: 30},
+	{Label: "6 months - 1 year", MinDays: 182},
+	{Label: "1 - 3 years", MinDays: 365},
+	{Label: "3 - 5 years", MinDays: 3 * 365},
+	{Label: "> 5 years", MinDays: 5 * 365},
+}
+
+// GetStargazerProfiles returns the profiles of the latest maxStargazers stargazers of a repo,
+// newest first, or of all of them if maxStargazers is not positive.
+// Each stargazer comes with up to 10 of its public organizations.
+func (c *ClientGQL) GetStargazerProfiles(ctx context.Context, ghRepo string, maxStargazers int, updateChannel chan<- int) ([]stats.StargazerProfile, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	variables := map[string]any{
+		"owner":       githubv4.String(repoSplit[0]),
+		"name":        githubv4.String(repoSplit[1]),
+		"starsCursor": (*githubv4.String)(nil),
+	}
+
+	var query struct {
+		Repository struct {
+			Stargazers struct {
+				Edges []struct {
+					StarredAt time.Time
+					Node      struct {
+						Login     string
+						Name      string
+						Company   string
+						Location  string
+						CreatedAt time.Time
+						Followers struct {
+							TotalCount int
+						}
+						Repositories struct {
+							TotalCount int
+						}
+						Organizations struct {
+							Nodes []struct {
+								Login string
+							}
+						} `graphql:"organizations(first: 10)"`
+					}
+				}
+				PageInfo struct {
+					StartCursor     githubv4.String
+					HasPreviousPage bool
+				}
+			} `graphql:"stargazers(last: 100, before: $starsCursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	result := []stats.StargazerProfile{}
+	counter := &Counter{}
+	now := c.opts.clock.Now()
+
+	for {
+		err := c.query(ctx, &query, variables)
+		if err != nil {
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetStargazerProfiles", "error", err)
+			return result, partialResult(err)
+		}
+
+		edges := query.Repository.Stargazers.Edges
+		for i := len(edges) - 1; i >= 0; i-- {
+			edge := edges[i]
+
+			// starred after the clock time
+			if edge.StarredAt.After(now) {
+				continue
+			}
+
+			organizations := make([]string, 0, len(edge.Node.Organizations.Nodes))
+			for _, org := range edge.Node.Organizations.Nodes {
+				organizations = append(organizations, org.Login)
+			}
+
+			result = append(result, stats.StargazerProfile{
+				Login:         edge.Node.Login,
+				Name:          edge.Node.Name,
+				Company:       edge.Node.Company,
+				Location:      edge.Node.Location,
+				Followers:     edge.Node.Followers.TotalCount,
+				Repositories:  edge.Node.Repositories.TotalCount,
+				CreatedAt:     edge.Node.CreatedAt,
+				Organizations: organizations,
+				StarredAt:     edge.StarredAt,
+			})
+
+			if maxStargazers > 0 && len(result) == maxStargazers {
+				return result, nil
+			}
+		}
+
+		counter.Increment()
+
+		if updateChannel != nil {
+			updateChannel <- counter.Value()
+		}
+
+		if len(edges) == 0 || !query.Repository.Stargazers.PageInfo.HasPreviousPage {
+			break
+		}
+
+		variables["starsCursor"] = githubv4.NewString(query.Repository.Stargazers.PageInfo.StartCursor)
+	}
+
+	return result, nil
+}
+
+// BuildAudienceReport aggregates stargazer profiles, keeping the topN most frequent
+// companies, locations and organizations. Account ages are measured when starring.
+func BuildAudienceReport(profiles []stats.StargazerProfile, topN int) stats.AudienceReport {
+	report := stats.AudienceReport{
+		Stargazers: len(profiles),
+		AccountAge: slices.Clone(accountAgeBuckets),
+	}
+
+	companies := newNameCounter()
+	locations := newNameCounter()
+	organizations := newNameCounter()
+
+	for _, profile := range profiles {
+		// "@acme" and "Acme " are the same company
+		companies.add(strings.TrimPrefix(strings.TrimSpace(profile.Company), "@"))
+		locations.add(profile.Location)
+		for _, org := range profile.Organizations {
+			organizations.add(org)
+		}
+
+		if profile.CreatedAt.IsZero() || profile.StarredAt.IsZero() {
+			continue
+		}
+
+		age := profile.StarredAt.Sub(profile.CreatedAt)
+		if age < newAccountAge {
+			report.NewAccounts++
+		}
+
+		days := int(age.Hours() / 24)
+		for i := len(report.AccountAge) - 1; i >= 0; i-- {
+			if days >= report.AccountAge[i].MinDays || i == 0 {
+				report.AccountAge[i].Count++
+				break
+			}
+		}
+	}
+
+	if report.Stargazers > 0 {
+		report.NewAccountsShare = float64(report.NewAccounts) / float64(report.Stargazers)
+	}
+
+	report.TopCompanies = companies.top(topN)
+	report.TopLocations = locations.top(topN)
+	report.TopOrganizations = organizations.top(topN)
+
+	return report
+}
+
+// nameCounter counts names case insensitively, reporting each one as first seen.
+type nameCounter struct {
+	counts map[string]*stats.NameCount
+}
+
+func newNameCounter() *nameCounter {
+	return &nameCounter{counts: map[string]*stats.NameCount{}}
+}
+
+func (n *nameCounter) add(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+
+	key := strings.ToLower(name)
+	if _, ok := n.counts[key]; !ok {
+		n.counts[key] = &stats.NameCount{Name: name}
+	}
+	n.counts[key].Count++
+}
+
+// top returns the limit most frequent names, or all of them if limit is not positive.
+func (n *nameCounter) top(limit int) []stats.NameCount {
+	result := make([]stats.NameCount, 0, len(n.counts))
+	for _, count := range n.counts {
+		result = append(result, *count)
+	}
+
+	slices.SortFunc(result, func(a, b stats.NameCount) int {
+		return cmp.Or(b.Count-a.Count, strings.Compare(a.Name, b.Name))
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
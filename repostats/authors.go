@@ -0,0 +1,236 @@
+package repostats
+
+import (
+	"cmp"
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+	"github.com/shurcooL/githubv4"
+)
+
+// noreplyEmail matches the private emails GitHub gives to users, like 1234+login@users.noreply.github.com.
+var noreplyEmail = regexp.MustCompile(`^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+
+// isBotAuthor reports whether a commit author is an automation account, like
+// dependabot[bot] or renovate-bot.
+func isBotAuthor(login, name, email string) bool {
+	for _, value := range []string{login, name, email} {
+		value = strings.ToLower(value)
+		if strings.Contains(value, "[bot]") {
+			return true
+		}
+	}
+
+	login = strings.ToLower(login)
+	return strings.HasSuffix(login, "-bot") || strings.HasSuffix(strings.ToLower(name), "-bot")
+}
+
+// GetCommitAuthors returns the authors of the commits of the default branch, from the one with
+// the most commits. Commits are attributed to the GitHub user of the author when there is one,
+// and otherwise to the user seen with the same email or named by a noreply email, so that
+// an author committing from several emails is counted once. Bots are left out unless includeBots.
+func (c *ClientGQL) GetCommitAuthors(ctx context.Context, ghRepo string, includeBots bool, updateChannel chan<- int) ([]stats.CommitAuthor, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	type commit struct {
+		CommittedDate time.Time
+		Additions     int
+		Deletions     int
+		Author        struct {
+			Name  string
+			Email string
+			User  struct {
+				Login string
+			}
+		}
+	}
+
+	variablesCommits := map[string]any{
+		"owner":         githubv4.String(repoSplit[0]),
+		"name":          githubv4.String(repoSplit[1]),
+		"commitsCursor": (*githubv4.String)(nil),
+	}
+
+	var queryCommits struct {
+		Repository struct {
+			DefaultBranchRef struct {
+				Target struct {
+					Commit struct {
+						History struct {
+							Nodes    []commit
+							PageInfo struct {
+								EndCursor   githubv4.String
+								HasNextPage bool
+							}
+						} `graphql:"history(first: 100, after: $commitsCursor)"`
+					} `graphql:"... on Commit"`
+				}
+			}
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	now := c.opts.clock.Now()
+	counter := &Counter{}
+	commits := []commit{}
+
+	for {
+		err := c.query(ctx, &queryCommits, variablesCommits)
+		if err != nil {
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetCommitAuthors", "error", err)
+			return nil, err
+		}
+
+		history := queryCommits.Repository.DefaultBranchRef.Target.Commit.History
+
+		for _, commit := range history.Nodes {
+			if !commit.CommittedDate.After(now) {
+				commits = append(commits, commit)
+			}
+		}
+
+		counter.Increment()
+
+		if updateChannel != nil {
+			updateChannel <- counter.Value()
+		}
+
+		if len(history.Nodes) == 0 || !history.PageInfo.HasNextPage {
+			break
+		}
+
+		variablesCommits["commitsCursor"] = githubv4.NewString(history.PageInfo.EndCursor)
+	}
+
+	// emails seen with a GitHub user, to attribute the commits of the same email without one
+	emailLogins := map[string]string{}
+	for _, commit := range commits {
+		if commit.Author.User.Login != "" && commit.Author.Email != "" {
+			emailLogins[strings.ToLower(commit.Author.Email)] = commit.Author.User.Login
+		}
+	}
+
+	authors := map[string]*stats.CommitAuthor{}
+	activeDays := map[string]map[time.Time]struct{}{}
+
+	for _, commit := range commits {
+		email := strings.ToLower(commit.Author.Email)
+
+		login := commit.Author.User.Login
+		if login == "" {
+			login = emailLogins[email]
+		}
+		if login == "" {
+			if match := noreplyEmail.FindStringSubmatch(email); match != nil {
+				login = match[1]
+			}
+		}
+
+		key := "login:" + strings.ToLower(login)
+		if login == "" {
+			key = "email:" + email
+		}
+		if login == "" && email == "" {
+			key = "name:" + commit.Author.Name
+		}
+
+		author, ok := authors[key]
+		if !ok {
+			author = &stats.CommitAuthor{Login: login, Name: commit.Author.Name, Emails: []string{}}
+			authors[key] = author
+			activeDays[key] = map[time.Time]struct{}{}
+		}
+
+		if email != "" && !slices.Contains(author.Emails, email) {
+			author.Emails = append(author.Emails, email)
+		}
+
+		author.Commits++
+		author.Additions += commit.Additions
+		author.Deletions += commit.Deletions
+
+		if author.FirstCommit.IsZero() || commit.CommittedDate.Before(author.FirstCommit) {
+			author.FirstCommit = commit.CommittedDate
+		}
+		if commit.CommittedDate.After(author.LastCommit) {
+			author.LastCommit = commit.CommittedDate
+			if commit.Author.Name != "" {
+				author.Name = commit.Author.Name
+			}
+		}
+
+		activeDays[key][c.opts.dayStart(commit.CommittedDate)] = struct{}{}
+
+		if isBotAuthor(login, commit.Author.Name, email) {
+			author.IsBot = true
+		}
+	}
+
+	result := make([]stats.CommitAuthor, 0, len(authors))
+	for key, author := range authors {
+		if author.IsBot && !includeBots {
+			continue
+		}
+		author.ActiveDays = len(activeDays[key])
+		slices.Sort(author.Emails)
+		result = append(result, *author)
+	}
+
+	slices.SortFunc(result, func(a, b stats.CommitAuthor) int {
+		return cmp.Or(b.Commits-a.Commits, strings.Compare(a.Login, b.Login), strings.Compare(a.Name, b.Name))
+	})
+
+	return result, nil
+}
+
+// SummarizeCommitAuthors computes the bus factor, also known as truck factor, and the
+// concentration of the commits of authors, as returned by GetCommitAuthors.
+func SummarizeCommitAuthors(authors []stats.CommitAuthor) stats.CommitAuthorsSummary {
+	summary := stats.CommitAuthorsSummary{Authors: len(authors)}
+
+	commits := make([]int, 0, len(authors))
+	for _, author := range authors {
+		commits = append(commits, author.Commits)
+		summary.Commits += author.Commits
+	}
+
+	if summary.Commits == 0 {
+		return summary
+	}
+
+	// from the least active author
+	slices.Sort(commits)
+
+	covered := 0
+	for i := len(commits) - 1; i >= 0; i-- {
+		covered += commits[i]
+		summary.BusFactor++
+		if 2*covered > summary.Commits {
+			break
+		}
+	}
+
+	summary.TopAuthorShare = float64(commits[len(commits)-1]) / float64(summary.Commits)
+
+	weighted := 0.0
+	for i, count := range commits {
+		weighted += float64(i+1) * float64(count)
+	}
+	n := float64(len(commits))
+	summary.Gini = 2*weighted/(n*float64(summary.Commits)) - (n+1)/n
+
+	return summary
+}
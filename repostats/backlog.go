@@ -0,0 +1,160 @@
+package repostats
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+// openInterval is when an item was open, a zero closedAt meaning still open.
+type openInterval struct {
+	createdAt time.Time
+	closedAt  time.Time
+}
+
+// backlogItem are the fields of issues and pull requests needed for the backlog.
+type backlogItem struct {
+	Number    int
+	Title     string
+	URL       string
+	State     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ClosedAt  time.Time
+	Author    struct {
+		Login string
+	}
+}
+
+// GetBacklogReport returns, for each day from the creation of a repo, how many issues and
+// pull requests were open at its end, by age, and the items open at the clock time without
+// activity for at least staleDays days, the most idle first. Unlike TotalCurrentlyOpen of
+// GetAllIssuesHistory, the backlog of a day counts the items open on that day, even if
+// they were closed later.
+func (c *ClientGQL) GetBacklogReport(ctx context.Context, ghRepo string, staleDays int, updateChannel chan<- int) (stats.BacklogReport, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return stats.BacklogReport{}, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
+	if err != nil {
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetBacklogReport", "error", err)
+		return stats.BacklogReport{}, err
+	}
+
+	now := c.opts.clock.Now()
+	counter := &Counter{}
+	stale := []stats.StaleItem{}
+
+	collect := func(intervals *[]openInterval, isPullRequest bool) func(backlogItem) {
+		return func(item backlogItem) {
+			if item.CreatedAt.After(now) {
+				return
+			}
+
+			// closed after the clock time means still open at that time
+			interval := openInterval{createdAt: item.CreatedAt}
+			if item.State != "OPEN" && !item.ClosedAt.IsZero() && !item.ClosedAt.After(now) {
+				interval.closedAt = item.ClosedAt
+			}
+			*intervals = append(*intervals, interval)
+
+			if !interval.closedAt.IsZero() || item.UpdatedAt.After(now) {
+				return
+			}
+
+			idleDays := int(now.Sub(item.UpdatedAt).Hours() / 24)
+			if idleDays >= staleDays {
+				stale = append(stale, stats.StaleItem{
+					Number:        item.Number,
+					Title:         item.Title,
+					URL:           item.URL,
+					IsPullRequest: isPullRequest,
+					Author:        item.Author.Login,
+					CreatedAt:     item.CreatedAt,
+					UpdatedAt:     item.UpdatedAt,
+					IdleDays:      idleDays,
+				})
+			}
+		}
+	}
+
+	issues := []openInterval{}
+	err = paginateIssues(ctx, c, ghRepo, "GetBacklogReport", counter, updateChannel, collect(&issues, false))
+	if err != nil {
+		return stats.BacklogReport{}, err
+	}
+
+	prs := []openInterval{}
+	err = paginatePullRequests(ctx, c, ghRepo, "GetBacklogReport", counter, updateChannel, collect(&prs, true))
+	if err != nil {
+		return stats.BacklogReport{}, err
+	}
+
+	slices.SortFunc(stale, func(a, b stats.StaleItem) int {
+		return cmp.Or(b.IdleDays-a.IdleDays, a.Number-b.Number)
+	})
+
+	firstDay := c.opts.dayStart(repoCreationDate)
+
+	return stats.BacklogReport{
+		Issues:       c.backlogHistory(issues, firstDay, now),
+		PullRequests: c.backlogHistory(prs, firstDay, now),
+		Stale:        stale,
+	}, nil
+}
+
+// backlogHistory counts the intervals open at the end of each day from firstDay to the one of now,
+// the last day ending at now.
+func (c *ClientGQL) backlogHistory(intervals []openInterval, firstDay, now time.Time) []stats.BacklogDay {
+	days := c.opts.daysBetween(firstDay, now) + 1
+
+	result := make([]stats.BacklogDay, days)
+	dayEnds := make([]time.Time, days)
+	for i := range result {
+		result[i].Day = stats.JSONDay(firstDay.AddDate(0, 0, i))
+		dayEnds[i] = firstDay.AddDate(0, 0, i+1)
+	}
+	dayEnds[days-1] = now
+
+	for _, interval := range intervals {
+		// open at the end of the days from the one it was created in to the one before it was closed
+		from := max(0, c.opts.daysBetween(firstDay, interval.createdAt))
+		to := days - 1
+		if !interval.closedAt.IsZero() {
+			to = c.opts.daysBetween(firstDay, interval.closedAt) - 1
+		}
+
+		for i := from; i <= to; i++ {
+			day := &result[i]
+			day.Open++
+
+			switch age := dayEnds[i].Sub(interval.createdAt).Hours() / 24; {
+			case age < 7:
+				day.Under7d++
+			case age < 30:
+				day.Under30d++
+			case age < 90:
+				day.Under90d++
+			case age < 365:
+				day.Under1y++
+			default:
+				day.Older++
+			}
+		}
+	}
+
+	return result
+}
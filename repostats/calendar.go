@@ -0,0 +1,43 @@
+package repostats
+
+import "time"
+
+// dayStart returns the midnight starting the day of t in the configured location.
+func (o *options) dayStart(t time.Time) time.Time {
+	year, month, day := t.In(o.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, o.location)
+}
+
+// daysBetween returns the number of days from the day of start to the day of t in the
+// configured location, negative if t is on an earlier day. Days lasting 23 or 25 hours
+// because of DST count as one.
+func (o *options) daysBetween(start, t time.Time) int {
+	startYear, startMonth, startDay := start.In(o.location).Date()
+	year, month, day := t.In(o.location).Date()
+
+	from := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// hourStart returns the start of the hour of t on the clock of the configured location,
+// which is not a multiple of an hour since the epoch for zones like Asia/Kolkata.
+func (o *options) hourStart(t time.Time) time.Time {
+	local := t.In(o.location)
+	return local.Add(-time.Duration(local.Minute())*time.Minute -
+		time.Duration(local.Second())*time.Second -
+		time.Duration(local.Nanosecond()))
+}
+
+// searchDayRange returns the search range covering the day starting at day. GitHub reads
+// plain dates as UTC, so other locations get timestamps with their offset.
+func (o *options) searchDayRange(day time.Time) string {
+	if o.location == time.UTC {
+		date := day.Format("2006-01-02")
+		return date + ".." + date
+	}
+
+	end := day.AddDate(0, 0, 1).Add(-time.Second)
+	return day.Format(time.RFC3339) + ".." + end.Format(time.RFC3339)
+}
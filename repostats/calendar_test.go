@@ -0,0 +1,141 @@
+package repostats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+)
+
+// DST starts on 2024-03-31 in Rome, a 23 hours day, and ends on 2024-10-27, a 25 hours day.
+func romeDSTClient(t *testing.T) (*ClientGQL, *time.Location) {
+	t.Helper()
+
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+
+	cest := time.FixedZone("CEST", 2*60*60)
+	cet := time.FixedZone("CET", 60*60)
+
+	stargazers := stargazersAt(
+		time.Date(2024, 3, 30, 23, 30, 0, 0, cet),
+		time.Date(2024, 3, 31, 0, 30, 0, 0, cet), // still March 30 in UTC
+		time.Date(2024, 3, 31, 23, 30, 0, 0, cest),
+		time.Date(2024, 4, 1, 0, 30, 0, 0, cest), // still March 31 in UTC
+		time.Date(2024, 10, 27, 0, 30, 0, 0, cest),
+		time.Date(2024, 10, 27, 2, 30, 0, 0, cest), // 02:30 comes twice
+		time.Date(2024, 10, 27, 2, 30, 0, 0, cet),
+		time.Date(2024, 10, 27, 23, 30, 0, 0, cet),
+		time.Date(2024, 10, 28, 0, 30, 0, 0, cet), // still October 27 in UTC
+	)
+
+	srv := ghfake.NewServer(&ghfake.Repo{
+		Owner:      "octo",
+		Name:       "repo",
+		CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, rome),
+		Stargazers: stargazers,
+	})
+	t.Cleanup(srv.Close)
+
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, rome)
+	client := NewClientGQL(srv.Client(), WithEnterpriseServer(srv.URL), WithClock(FixedClock(now)), WithLocation(rome))
+
+	return client, rome
+}
+
+func TestStarsHistoryAcrossDST(t *testing.T) {
+	client, rome := romeDSTClient(t)
+
+	got, err := client.GetAllStarsHistoryTwoWays(context.Background(), "octo/repo", nil)
+	if err != nil {
+		t.Fatalf("GetAllStarsHistoryTwoWays() error = %v", err)
+	}
+
+	// from January 1 to November 1
+	if len(got) != 306 {
+		t.Fatalf("got %d days, want 306", len(got))
+	}
+
+	date := func(month time.Month, day int) time.Time { return time.Date(2024, month, day, 0, 0, 0, 0, rome) }
+	wantDays := map[time.Time]int{
+		date(time.March, 30):   1,
+		date(time.March, 31):   2,
+		date(time.April, 1):    1,
+		date(time.October, 27): 4,
+		date(time.October, 28): 1,
+	}
+
+	for i, day := range got {
+		if want := date(time.January, 1+i); !time.Time(day.Day).Equal(want) || time.Time(day.Day).Location() != rome {
+			t.Fatalf("day %d = %v, want %v", i, time.Time(day.Day), want)
+		}
+		if want := wantDays[time.Time(day.Day)]; day.Stars != want {
+			t.Errorf("day %s: got %d stars, want %d", time.Time(day.Day).Format(time.DateOnly), day.Stars, want)
+		}
+	}
+
+	if total := got[len(got)-1].TotalStars; total != 9 {
+		t.Errorf("TotalStars = %d, want 9", total)
+	}
+}
+
+func TestStarsHistoryByHourAcrossDST(t *testing.T) {
+	client, rome := romeDSTClient(t)
+
+	tests := []struct {
+		name      string
+		day       time.Time
+		hours     int         // hours of the day
+		wantHours []int       // clock hour of the first buckets
+		wantStars map[int]int // stars by bucket
+	}{
+		{
+			name:      "23 hours day",
+			day:       time.Date(2024, 3, 31, 0, 0, 0, 0, rome),
+			hours:     23,
+			wantHours: []int{0, 1, 3, 4},
+			wantStars: map[int]int{0: 1, 22: 1},
+		},
+		{
+			name:      "25 hours day",
+			day:       time.Date(2024, 10, 27, 0, 0, 0, 0, rome),
+			hours:     25,
+			wantHours: []int{0, 1, 2, 2, 3},
+			wantStars: map[int]int{0: 1, 2: 1, 3: 1, 24: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.day.AddDate(0, 0, 1)
+
+			got, err := client.GetRecentStarsHistoryByHourRange(context.Background(), "octo/repo", tt.day, next, nil)
+			if err != nil {
+				t.Fatalf("GetRecentStarsHistoryByHourRange() error = %v", err)
+			}
+
+			// the buckets of the day and the one of the following midnight
+			if len(got) != tt.hours+1 {
+				t.Fatalf("got %d hours, want %d", len(got), tt.hours+1)
+			}
+			if last := got[len(got)-1].Hour; !last.Equal(next) {
+				t.Errorf("last hour = %v, want %v", last, next)
+			}
+
+			for i, hour := range tt.wantHours {
+				if got[i].Hour.In(rome).Hour() != hour {
+					t.Errorf("bucket %d starts at %v, want %02d:00", i, got[i].Hour.In(rome), hour)
+				}
+			}
+
+			for i, hour := range got {
+				if hour.Stars != tt.wantStars[i] {
+					t.Errorf("bucket %d (%v): got %d stars, want %d", i, hour.Hour.In(rome), hour.Stars, tt.wantStars[i])
+				}
+			}
+		})
+	}
+}
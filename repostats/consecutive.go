@@ -1,7 +1,12 @@
 package repostats
 
 import (
-	"sort"
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
 
 	"github.com/emanuelef/github-repo-activity-stats/stats"
 )
@@ -17,28 +22,24 @@ type PeakDay struct {
 	Stars int
 }
 
+// FindMaxConsecutivePeriods returns the windows of consecutiveDays days with the most stars,
+// all of them in case of ties, and the days with the most stars.
+// An empty history gives no periods and no peak days.
 func FindMaxConsecutivePeriods(starsData []stats.StarsPerDay, consecutiveDays int) ([]MaxPeriod, []PeakDay, error) {
 	var maxPeriods []MaxPeriod
 	var peakDays []PeakDay
 
-	// Calculate maxSum and maxPeriods for consecutive periods
-	maxSum := 0
-	for i := 0; i <= len(starsData)-consecutiveDays; i++ {
-		sum := 0
-		for j := i; j < i+consecutiveDays; j++ {
-			sum += starsData[j].Stars
-		}
+	stars := func(day stats.StarsPerDay) int { return day.Stars }
+
+	sums := windowSums(starsData, stars, consecutiveDays)
 
+	maxSum := 0
+	for i, sum := range sums {
 		if sum > maxSum {
 			maxSum = sum
-			maxPeriods = []MaxPeriod{
-				{
-					StartDay:   starsData[i].Day,
-					EndDay:     starsData[i+consecutiveDays-1].Day,
-					TotalStars: sum,
-				},
-			}
-		} else if sum == maxSum {
+			maxPeriods = maxPeriods[:0]
+		}
+		if sum == maxSum {
 			maxPeriods = append(maxPeriods, MaxPeriod{
 				StartDay:   starsData[i].Day,
 				EndDay:     starsData[i+consecutiveDays-1].Day,
@@ -47,32 +48,191 @@ func FindMaxConsecutivePeriods(starsData []stats.StarsPerDay, consecutiveDays in
 		}
 	}
 
-	// Calculate peakDays
-	starMap := make(map[stats.JSONDay]int)
-	for _, data := range starsData {
-		starMap[data.Day] = data.Stars
+	for i, day := range starsData {
+		if i == 0 || day.Stars > peakDays[0].Stars {
+			peakDays = peakDays[:0]
+		}
+		if len(peakDays) == 0 || day.Stars == peakDays[0].Stars {
+			peakDays = append(peakDays, PeakDay{Day: day.Day, Stars: day.Stars})
+		}
+	}
+
+	return maxPeriods, peakDays, nil
+}
+
+// Window is a run of consecutive days of a timeline and the sum of a metric over them.
+type Window struct {
+	StartDay stats.JSONDay
+	EndDay   stats.JSONDay
+	Total    int
+}
+
+// Streak is a run of consecutive days with, or without, activity.
+type Streak struct {
+	StartDay stats.JSONDay
+	EndDay   stats.JSONDay
+	Days     int
+}
+
+// dated is implemented by the per-day timeline types, like stats.CommitsPerDay.
+type dated interface {
+	Date() time.Time
+}
+
+// windowSums returns the sum of value over each window of windowDays days of timeline,
+// indexed by the first day of the window.
+func windowSums[T any](timeline []T, value func(T) int, windowDays int) []int {
+	if windowDays <= 0 || windowDays > len(timeline) {
+		return nil
+	}
+
+	sums := make([]int, 0, len(timeline)-windowDays+1)
+
+	sum := 0
+	for i, day := range timeline {
+		sum += value(day)
+		if i >= windowDays {
+			sum -= value(timeline[i-windowDays])
+		}
+		if i >= windowDays-1 {
+			sums = append(sums, sum)
+		}
 	}
 
-	// Sort the days by stars in descending order
-	var sortedDays []PeakDay
-	for day, stars := range starMap {
-		sortedDays = append(sortedDays, PeakDay{Day: day, Stars: stars})
+	return sums
+}
+
+// TopWindows returns the k non-overlapping windows of windowDays days where value sums the
+// most, from the highest total, earlier windows winning ties. value picks the metric,
+// like func(d stats.CommitsPerDay) int { return d.Commits }.
+func TopWindows[T dated](timeline []T, value func(T) int, windowDays, k int) []Window {
+	return selectWindows(timeline, value, windowDays, k, func(a, b int) int { return b - a })
+}
+
+// QuietestWindows returns the k non-overlapping windows of windowDays days where value sums
+// the least, from the lowest total, earlier windows winning ties.
+func QuietestWindows[T dated](timeline []T, value func(T) int, windowDays, k int) []Window {
+	return selectWindows(timeline, value, windowDays, k, func(a, b int) int { return a - b })
+}
+
+// selectWindows picks greedily the best windows according to compare that don't overlap
+// the ones already picked.
+//
+// A picked window overlaps at most 2*windowDays-1 windows, itself included, so the k windows
+// are among the best k*(2*windowDays-1): a heap keeps only those, in O(n log(k*windowDays))
+// time for n days instead of sorting every window.
+func selectWindows[T dated](timeline []T, value func(T) int, windowDays, k int, compare func(a, b int) int) []Window {
+	result := []Window{}
+
+	sums := windowSums(timeline, value, windowDays)
+	if k <= 0 || len(sums) == 0 {
+		return result
 	}
-	sort.Slice(sortedDays, func(i, j int) bool {
-		return sortedDays[i].Stars > sortedDays[j].Stars
-	})
 
-	// Find the days with maximum stars
-	maxStars := sortedDays[0].Stars
-	for _, day := range sortedDays {
-		if day.Stars == maxStars {
-			peakDays = append(peakDays, day)
-		} else {
-			break // Days are sorted, so no need to check further
+	// earlier windows win ties
+	better := func(a, b int) int {
+		if c := compare(sums[a], sums[b]); c != 0 {
+			return c
 		}
+		return a - b
 	}
 
-	return maxPeriods, peakDays, nil
+	candidates := len(sums)
+	if k < len(sums)/(2*windowDays-1) {
+		candidates = k * (2*windowDays - 1)
+	}
+
+	h := &windowHeap{worse: func(a, b int) bool { return better(a, b) > 0 }}
+	for start := range sums {
+		if h.Len() < candidates {
+			heap.Push(h, start)
+			continue
+		}
+		if better(start, h.starts[0]) < 0 {
+			h.starts[0] = start
+			heap.Fix(h, 0)
+		}
+	}
+
+	starts := h.starts
+	slices.SortFunc(starts, better)
+
+	// windows having the same length, an overlapping one contains a taken start or end day
+	taken := make([]bool, len(timeline))
+
+	for _, start := range starts {
+		end := start + windowDays - 1
+		if taken[start] || taken[end] {
+			continue
+		}
+
+		for i := start; i <= end; i++ {
+			taken[i] = true
+		}
+
+		result = append(result, Window{
+			StartDay: stats.JSONDay(timeline[start].Date()),
+			EndDay:   stats.JSONDay(timeline[end].Date()),
+			Total:    sums[start],
+		})
+
+		if len(result) == k {
+			break
+		}
+	}
+
+	return result
+}
+
+// windowHeap is a heap of window starts with the worst one on top.
+type windowHeap struct {
+	starts []int
+	worse  func(a, b int) bool
+}
+
+func (h *windowHeap) Len() int           { return len(h.starts) }
+func (h *windowHeap) Less(i, j int) bool { return h.worse(h.starts[i], h.starts[j]) }
+func (h *windowHeap) Swap(i, j int)      { h.starts[i], h.starts[j] = h.starts[j], h.starts[i] }
+func (h *windowHeap) Push(x any)         { h.starts = append(h.starts, x.(int)) }
+
+func (h *windowHeap) Pop() any {
+	last := h.starts[len(h.starts)-1]
+	h.starts = h.starts[:len(h.starts)-1]
+	return last
+}
+
+// LongestStreaks returns the longest runs of days with value above zero and with value
+// zero, the earliest one in case of ties. A missing streak has zero Days.
+func LongestStreaks[T dated](timeline []T, value func(T) int) (active, inactive Streak) {
+	runStart := 0
+
+	for i := range timeline {
+		isActive := value(timeline[i]) > 0
+
+		if i > 0 && (value(timeline[i-1]) > 0) != isActive {
+			runStart = i
+		}
+
+		// the run ends here if the next day differs or there is none
+		if i+1 < len(timeline) && (value(timeline[i+1]) > 0) == isActive {
+			continue
+		}
+
+		streak := Streak{
+			StartDay: stats.JSONDay(timeline[runStart].Date()),
+			EndDay:   stats.JSONDay(timeline[i].Date()),
+			Days:     i - runStart + 1,
+		}
+
+		if isActive && streak.Days > active.Days {
+			active = streak
+		}
+		if !isActive && streak.Days > inactive.Days {
+			inactive = streak
+		}
+	}
+
+	return active, inactive
 }
 
 func NewStarsLastDays(starsData []stats.StarsPerDay, days int) int {
@@ -89,3 +249,229 @@ func NewStarsLastDays(starsData []stats.StarsPerDay, days int) int {
 
 	return sum
 }
+
+const (
+	// forecastSeason is the length of the seasonality of the daily stars, a week.
+	forecastSeason = 7
+	// forecastHistoryDays is the number of latest days the forecast model is fitted on.
+	forecastHistoryDays = 365
+	// forecastPaths is the number of simulated futures the confidence intervals come from.
+	forecastPaths = 500
+	// DefaultForecastConfidence is used when the confidence isn't between 0 and 1.
+	DefaultForecastConfidence = 0.8
+)
+
+// ForecastDay is the projection of a day after the stars history.
+// Lower and Upper bound TotalStars within the requested confidence.
+type ForecastDay struct {
+	Day        stats.JSONDay
+	Stars      float64
+	TotalStars float64
+	Lower      float64
+	Upper      float64
+}
+
+// Milestone tells when a repo is expected to reach Stars.
+// Dates not reached within the forecast horizon are left zero.
+type Milestone struct {
+	Stars    int
+	Reached  bool          // the history already reached Stars, on Date
+	Date     stats.JSONDay // expected day
+	Earliest stats.JSONDay // day reached by the upper bound
+	Latest   stats.JSONDay // day reached by the lower bound
+}
+
+// holtWinters is an additive Holt-Winters model with damped trend, in error correction form.
+type holtWinters struct {
+	alpha, beta, gamma, phi float64
+
+	level  float64
+	trend  float64
+	season [forecastSeason]float64
+	// t is the index of the next day, selecting its seasonal component
+	t int
+}
+
+// forecast returns the expected value of the next day.
+func (m *holtWinters) forecast() float64 {
+	return m.level + m.phi*m.trend + m.season[m.t%forecastSeason]
+}
+
+// update moves the model to the following day, y being the value observed for the current one.
+// Returns the error of the forecast.
+func (m *holtWinters) update(y float64) float64 {
+	e := y - m.forecast()
+	m.level += m.phi*m.trend + m.alpha*e
+	m.trend = m.phi*m.trend + m.beta*e
+	m.season[m.t%forecastSeason] += m.gamma * e
+	m.t++
+	return e
+}
+
+// fitHoltWinters runs the model over y with the given parameters, after initializing it on
+// the first two weeks, and returns it with the one step ahead errors.
+func fitHoltWinters(y []float64, alpha, beta, gamma, phi float64) (holtWinters, []float64) {
+	m := holtWinters{alpha: alpha, beta: beta, gamma: gamma, phi: phi}
+
+	first, second := 0.0, 0.0
+	for i := 0; i < forecastSeason; i++ {
+		first += y[i] / forecastSeason
+		second += y[i+forecastSeason] / forecastSeason
+	}
+
+	m.level = first
+	m.trend = (second - first) / forecastSeason
+	for i := 0; i < forecastSeason; i++ {
+		m.season[i] = y[i] - first
+	}
+	m.t = forecastSeason
+
+	residuals := make([]float64, 0, len(y)-forecastSeason)
+	for _, value := range y[forecastSeason:] {
+		residuals = append(residuals, m.update(value))
+	}
+
+	return m, residuals
+}
+
+// ForecastStars projects the stars of the days following starsData, a full and contiguous
+// history like the one of GetAllStarsHistoryTwoWays.
+//
+// The daily stars of the last year are fitted with an additive Holt-Winters model with a
+// damped trend and weekly seasonality, its parameters being chosen by grid search. Lower and
+// Upper come from simulating the model with its past errors, so that confidence of the
+// simulated totals falls between them, DefaultForecastConfidence if not between 0 and 1.
+func ForecastStars(starsData []stats.StarsPerDay, days int, confidence float64) ([]ForecastDay, error) {
+	if len(starsData) < 2*forecastSeason+1 {
+		return nil, fmt.Errorf("at least %d days are needed to forecast, got %d", 2*forecastSeason+1, len(starsData))
+	}
+
+	if days <= 0 {
+		return []ForecastDay{}, nil
+	}
+
+	if confidence <= 0 || confidence >= 1 {
+		confidence = DefaultForecastConfidence
+	}
+
+	history := starsData[max(0, len(starsData)-forecastHistoryDays):]
+	y := make([]float64, len(history))
+	for i, day := range history {
+		y[i] = float64(day.Stars)
+	}
+
+	var best holtWinters
+	var bestResiduals []float64
+	bestSSE := math.Inf(1)
+
+	for _, alpha := range []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.7} {
+		for _, betaShare := range []float64{0.01, 0.05, 0.1, 0.3} {
+			for _, gamma := range []float64{0.01, 0.05, 0.1, 0.2} {
+				for _, phi := range []float64{0.8, 0.9, 0.95, 0.98} {
+					model, residuals := fitHoltWinters(y, alpha, alpha*betaShare, gamma, phi)
+
+					sse := 0.0
+					for _, e := range residuals {
+						sse += e * e
+					}
+
+					if sse < bestSSE {
+						best, bestResiduals, bestSSE = model, residuals, sse
+					}
+				}
+			}
+		}
+	}
+
+	last := starsData[len(starsData)-1]
+	lastDay := time.Time(last.Day)
+	lastTotal := float64(last.TotalStars)
+
+	result := make([]ForecastDay, days)
+
+	expected := best
+	total := lastTotal
+	for i := range result {
+		stars := math.Max(0, expected.forecast())
+		expected.update(stars)
+		total += stars
+
+		result[i] = ForecastDay{
+			Day:        stats.JSONDay(lastDay.AddDate(0, 0, i+1)),
+			Stars:      stars,
+			TotalStars: total,
+		}
+	}
+
+	// simulated totals, by day then path
+	totals := make([][]float64, days)
+	for i := range totals {
+		totals[i] = make([]float64, forecastPaths)
+	}
+
+	// fixed seed, so that the same history gives the same forecast
+	rng := rand.New(rand.NewPCG(uint64(len(history)), uint64(last.TotalStars)))
+
+	for path := 0; path < forecastPaths; path++ {
+		simulated := best
+		total := lastTotal
+		for i := 0; i < days; i++ {
+			stars := simulated.forecast()
+			if len(bestResiduals) > 0 {
+				stars += bestResiduals[rng.IntN(len(bestResiduals))]
+			}
+			stars = math.Max(0, stars)
+			simulated.update(stars)
+			total += stars
+			totals[i][path] = total
+		}
+	}
+
+	lowerIndex := int(math.Floor((1 - confidence) / 2 * (forecastPaths - 1)))
+	upperIndex := int(math.Ceil((1 + confidence) / 2 * (forecastPaths - 1)))
+
+	for i := range result {
+		slices.Sort(totals[i])
+		result[i].Lower = math.Min(totals[i][lowerIndex], result[i].TotalStars)
+		result[i].Upper = math.Max(totals[i][upperIndex], result[i].TotalStars)
+	}
+
+	return result, nil
+}
+
+// EstimateMilestoneDate returns when the repo of starsData reaches target stars, looking at
+// most maxDays ahead with ForecastStars.
+func EstimateMilestoneDate(starsData []stats.StarsPerDay, target, maxDays int, confidence float64) (Milestone, error) {
+	milestone := Milestone{Stars: target}
+
+	for _, day := range starsData {
+		if day.TotalStars >= target {
+			milestone.Reached = true
+			milestone.Date = day.Day
+			milestone.Earliest = day.Day
+			milestone.Latest = day.Day
+			return milestone, nil
+		}
+	}
+
+	forecast, err := ForecastStars(starsData, maxDays, confidence)
+	if err != nil {
+		return milestone, err
+	}
+
+	var zero stats.JSONDay
+	for _, day := range forecast {
+		if milestone.Earliest == zero && day.Upper >= float64(target) {
+			milestone.Earliest = day.Day
+		}
+		if milestone.Date == zero && day.TotalStars >= float64(target) {
+			milestone.Date = day.Day
+		}
+		if milestone.Latest == zero && day.Lower >= float64(target) {
+			milestone.Latest = day.Day
+			break
+		}
+	}
+
+	return milestone, nil
+}
@@ -0,0 +1,424 @@
+package repostats
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+// timelineStart is the first day of the timelines built by starsTimeline.
+var timelineStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// starsTimeline returns a timeline from timelineStart with the given stars per day.
+func starsTimeline(stars ...int) []stats.StarsPerDay {
+	timeline := make([]stats.StarsPerDay, len(stars))
+	total := 0
+	for i, dayStars := range stars {
+		total += dayStars
+		timeline[i] = stats.StarsPerDay{Day: timelineDay(i), Stars: dayStars, TotalStars: total}
+	}
+	return timeline
+}
+
+// timelineDay returns the day i of the timelines built by starsTimeline.
+func timelineDay(i int) stats.JSONDay {
+	return stats.JSONDay(timelineStart.AddDate(0, 0, i))
+}
+
+func TestFindMaxConsecutivePeriods(t *testing.T) {
+	timeline := starsTimeline(1, 5, 2, 8, 0, 3, 8, 1)
+
+	periods, peakDays, err := FindMaxConsecutivePeriods(timeline, 3)
+	if err != nil {
+		t.Fatalf("FindMaxConsecutivePeriods() error = %v", err)
+	}
+
+	wantPeriods := []MaxPeriod{{StartDay: timelineDay(1), EndDay: timelineDay(3), TotalStars: 15}}
+	if !reflect.DeepEqual(periods, wantPeriods) {
+		t.Errorf("periods = %+v, want %+v", periods, wantPeriods)
+	}
+
+	wantPeakDays := []PeakDay{{Day: timelineDay(3), Stars: 8}, {Day: timelineDay(6), Stars: 8}}
+	if !reflect.DeepEqual(peakDays, wantPeakDays) {
+		t.Errorf("peak days = %+v, want %+v", peakDays, wantPeakDays)
+	}
+}
+
+func TestFindMaxConsecutivePeriodsEdgeCases(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeline    []stats.StarsPerDay
+		days        int
+		wantPeriods []MaxPeriod
+		wantPeaks   []PeakDay
+	}{
+		{
+			name: "empty",
+			days: 3,
+		},
+		{
+			name:      "window longer than history",
+			timeline:  starsTimeline(1, 4),
+			days:      3,
+			wantPeaks: []PeakDay{{Day: timelineDay(1), Stars: 4}},
+		},
+		{
+			name:      "zero days window",
+			timeline:  starsTimeline(1, 4),
+			days:      0,
+			wantPeaks: []PeakDay{{Day: timelineDay(1), Stars: 4}},
+		},
+		{
+			name:     "ties",
+			timeline: starsTimeline(2, 1, 2, 1),
+			days:     2,
+			wantPeriods: []MaxPeriod{
+				{StartDay: timelineDay(0), EndDay: timelineDay(1), TotalStars: 3},
+				{StartDay: timelineDay(1), EndDay: timelineDay(2), TotalStars: 3},
+				{StartDay: timelineDay(2), EndDay: timelineDay(3), TotalStars: 3},
+			},
+			wantPeaks: []PeakDay{{Day: timelineDay(0), Stars: 2}, {Day: timelineDay(2), Stars: 2}},
+		},
+		{
+			name:     "no stars",
+			timeline: starsTimeline(0, 0),
+			days:     2,
+			wantPeriods: []MaxPeriod{
+				{StartDay: timelineDay(0), EndDay: timelineDay(1), TotalStars: 0},
+			},
+			wantPeaks: []PeakDay{{Day: timelineDay(0), Stars: 0}, {Day: timelineDay(1), Stars: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods, peakDays, err := FindMaxConsecutivePeriods(tt.timeline, tt.days)
+			if err != nil {
+				t.Fatalf("FindMaxConsecutivePeriods() error = %v", err)
+			}
+			if !reflect.DeepEqual(periods, tt.wantPeriods) {
+				t.Errorf("periods = %+v, want %+v", periods, tt.wantPeriods)
+			}
+			if !reflect.DeepEqual(peakDays, tt.wantPeaks) {
+				t.Errorf("peak days = %+v, want %+v", peakDays, tt.wantPeaks)
+			}
+		})
+	}
+}
+
+// window returns the Window of the timelines built by starsTimeline from day start to end.
+func window(start, end, total int) Window {
+	return Window{StartDay: timelineDay(start), EndDay: timelineDay(end), Total: total}
+}
+
+func TestTopAndQuietestWindows(t *testing.T) {
+	stars := func(day stats.StarsPerDay) int { return day.Stars }
+
+	tests := []struct {
+		name     string
+		selector func([]stats.StarsPerDay, func(stats.StarsPerDay) int, int, int) []Window
+		timeline []stats.StarsPerDay
+		days     int
+		k        int
+		want     []Window
+	}{
+		{
+			name:     "top empty",
+			selector: TopWindows[stats.StarsPerDay],
+			days:     3,
+			k:        2,
+			want:     []Window{},
+		},
+		{
+			name:     "top window longer than history",
+			selector: TopWindows[stats.StarsPerDay],
+			timeline: starsTimeline(1, 2),
+			days:     3,
+			k:        2,
+			want:     []Window{},
+		},
+		{
+			name:     "top zero k",
+			selector: TopWindows[stats.StarsPerDay],
+			timeline: starsTimeline(1, 2, 3),
+			days:     1,
+			k:        0,
+			want:     []Window{},
+		},
+		{
+			name:     "top best",
+			selector: TopWindows[stats.StarsPerDay],
+			timeline: starsTimeline(1, 5, 2, 8, 0, 3, 8, 1),
+			days:     3,
+			k:        1,
+			want:     []Window{window(1, 3, 15)},
+		},
+		{
+			// the windows from day 3 and 4 sum 11 but overlap the ones already picked
+			name:     "top non overlapping",
+			selector: TopWindows[stats.StarsPerDay],
+			timeline: starsTimeline(1, 5, 2, 8, 0, 3, 8, 1),
+			days:     3,
+			k:        3,
+			want:     []Window{window(1, 3, 15), window(5, 7, 12)},
+		},
+		{
+			name:     "top ties",
+			selector: TopWindows[stats.StarsPerDay],
+			timeline: starsTimeline(2, 2, 2, 2),
+			days:     2,
+			k:        3,
+			want:     []Window{window(0, 1, 4), window(2, 3, 4)},
+		},
+		{
+			name:     "quietest non overlapping",
+			selector: QuietestWindows[stats.StarsPerDay],
+			timeline: starsTimeline(1, 5, 2, 8, 0, 3, 8, 1),
+			days:     2,
+			k:        3,
+			want:     []Window{window(4, 5, 3), window(0, 1, 6), window(6, 7, 9)},
+		},
+		{
+			name:     "quietest ties",
+			selector: QuietestWindows[stats.StarsPerDay],
+			timeline: starsTimeline(0, 0, 0, 0, 0),
+			days:     2,
+			k:        3,
+			want:     []Window{window(0, 1, 0), window(2, 3, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.selector(tt.timeline, stars, tt.days, tt.k)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("windows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// sortedWindows picks the windows like selectWindows, sorting every window start.
+func sortedWindows(timeline []stats.StarsPerDay, windowDays, k int, compare func(a, b int) int) []Window {
+	result := []Window{}
+
+	sums := windowSums(timeline, func(day stats.StarsPerDay) int { return day.Stars }, windowDays)
+	starts := make([]int, len(sums))
+	for i := range starts {
+		starts[i] = i
+	}
+	slices.SortStableFunc(starts, func(a, b int) int { return compare(sums[a], sums[b]) })
+
+	taken := make([]bool, len(timeline))
+	for _, start := range starts {
+		end := start + windowDays - 1
+		if len(result) == k || slices.Contains(taken[start:end+1], true) {
+			continue
+		}
+		for i := start; i <= end; i++ {
+			taken[i] = true
+		}
+		result = append(result, Window{StartDay: timeline[start].Day, EndDay: timeline[end].Day, Total: sums[start]})
+	}
+
+	return result
+}
+
+func TestTopWindowsHeap(t *testing.T) {
+	stars := func(day stats.StarsPerDay) int { return day.Stars }
+	r := rand.New(rand.NewPCG(1, 2))
+
+	for range 200 {
+		// few distinct values, for many ties
+		daily := make([]int, 1+r.IntN(120))
+		for i := range daily {
+			daily[i] = r.IntN(4)
+		}
+		timeline := starsTimeline(daily...)
+		days := 1 + r.IntN(10)
+		k := 1 + r.IntN(12)
+
+		if got, want := TopWindows(timeline, stars, days, k), sortedWindows(timeline, days, k, func(a, b int) int { return b - a }); !reflect.DeepEqual(got, want) {
+			t.Fatalf("TopWindows(%v, %d, %d) = %+v, want %+v", daily, days, k, got, want)
+		}
+		if got, want := QuietestWindows(timeline, stars, days, k), sortedWindows(timeline, days, k, func(a, b int) int { return a - b }); !reflect.DeepEqual(got, want) {
+			t.Fatalf("QuietestWindows(%v, %d, %d) = %+v, want %+v", daily, days, k, got, want)
+		}
+	}
+}
+
+func TestLongestStreaks(t *testing.T) {
+	stars := func(day stats.StarsPerDay) int { return day.Stars }
+
+	tests := []struct {
+		name         string
+		timeline     []stats.StarsPerDay
+		wantActive   Streak
+		wantInactive Streak
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:         "runs",
+			timeline:     starsTimeline(1, 0, 0, 2, 3, 4, 0, 0, 0, 1),
+			wantActive:   Streak{StartDay: timelineDay(3), EndDay: timelineDay(5), Days: 3},
+			wantInactive: Streak{StartDay: timelineDay(6), EndDay: timelineDay(8), Days: 3},
+		},
+		{
+			name:         "ties",
+			timeline:     starsTimeline(1, 1, 0, 1, 1),
+			wantActive:   Streak{StartDay: timelineDay(0), EndDay: timelineDay(1), Days: 2},
+			wantInactive: Streak{StartDay: timelineDay(2), EndDay: timelineDay(2), Days: 1},
+		},
+		{
+			name:       "always active",
+			timeline:   starsTimeline(1, 2, 3),
+			wantActive: Streak{StartDay: timelineDay(0), EndDay: timelineDay(2), Days: 3},
+		},
+		{
+			name:         "never active",
+			timeline:     starsTimeline(0, 0),
+			wantInactive: Streak{StartDay: timelineDay(0), EndDay: timelineDay(1), Days: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, inactive := LongestStreaks(tt.timeline, stars)
+			if active != tt.wantActive {
+				t.Errorf("active = %+v, want %+v", active, tt.wantActive)
+			}
+			if inactive != tt.wantInactive {
+				t.Errorf("inactive = %+v, want %+v", inactive, tt.wantInactive)
+			}
+		})
+	}
+}
+
+// forecastHistory returns days of history growing slowly, busier on weekends and with some
+// deterministic noise.
+func forecastHistory(days int) []stats.StarsPerDay {
+	stars := make([]int, days)
+	for i := range stars {
+		stars[i] = 10 + i/10 + (i*7919)%5
+		if i%7 >= 5 {
+			stars[i] += 6
+		}
+	}
+	return starsTimeline(stars...)
+}
+
+func TestForecastStars(t *testing.T) {
+	history := forecastHistory(120)
+	last := history[len(history)-1]
+
+	forecast, err := ForecastStars(history, 30, 0.9)
+	if err != nil {
+		t.Fatalf("ForecastStars() error = %v", err)
+	}
+
+	if len(forecast) != 30 {
+		t.Fatalf("got %d forecast days, want 30", len(forecast))
+	}
+
+	previous := float64(last.TotalStars)
+	for i, day := range forecast {
+		if want := timelineDay(len(history) + i); day.Day != want {
+			t.Errorf("day %d is %v, want %v", i, time.Time(day.Day), time.Time(want))
+		}
+		if day.Lower > day.TotalStars || day.TotalStars > day.Upper {
+			t.Errorf("day %d: TotalStars %.1f not within [%.1f, %.1f]", i, day.TotalStars, day.Lower, day.Upper)
+		}
+		if day.TotalStars < previous {
+			t.Errorf("day %d: TotalStars %.1f decreased from %.1f", i, day.TotalStars, previous)
+		}
+		previous = day.TotalStars
+	}
+
+	again, err := ForecastStars(history, 30, 0.9)
+	if err != nil {
+		t.Fatalf("ForecastStars() error = %v", err)
+	}
+	if !reflect.DeepEqual(forecast, again) {
+		t.Error("the same history gave a different forecast")
+	}
+}
+
+func TestForecastStarsShortHistory(t *testing.T) {
+	if _, err := ForecastStars(forecastHistory(14), 30, 0.8); err == nil {
+		t.Error("ForecastStars() with 14 days of history: want an error")
+	}
+
+	if _, err := ForecastStars(forecastHistory(15), 30, 0.8); err != nil {
+		t.Errorf("ForecastStars() with 15 days of history error = %v", err)
+	}
+
+	if _, err := EstimateMilestoneDate(forecastHistory(14), 1_000_000, 30, 0.8); err == nil {
+		t.Error("EstimateMilestoneDate() with 14 days of history: want an error")
+	}
+}
+
+func TestEstimateMilestoneDate(t *testing.T) {
+	history := forecastHistory(120)
+	last := history[len(history)-1]
+
+	t.Run("reached", func(t *testing.T) {
+		target := history[40].TotalStars
+
+		milestone, err := EstimateMilestoneDate(history, target, 365, 0.8)
+		if err != nil {
+			t.Fatalf("EstimateMilestoneDate() error = %v", err)
+		}
+
+		want := Milestone{
+			Stars:    target,
+			Reached:  true,
+			Date:     timelineDay(40),
+			Earliest: timelineDay(40),
+			Latest:   timelineDay(40),
+		}
+		if milestone != want {
+			t.Errorf("milestone = %+v, want %+v", milestone, want)
+		}
+	})
+
+	t.Run("forecast", func(t *testing.T) {
+		target := last.TotalStars + 500
+
+		milestone, err := EstimateMilestoneDate(history, target, 365, 0.8)
+		if err != nil {
+			t.Fatalf("EstimateMilestoneDate() error = %v", err)
+		}
+
+		if milestone.Reached {
+			t.Error("milestone Reached, want false")
+		}
+
+		date, earliest, latest := time.Time(milestone.Date), time.Time(milestone.Earliest), time.Time(milestone.Latest)
+		if date.IsZero() || earliest.IsZero() || latest.IsZero() {
+			t.Fatalf("milestone = %+v, want all dates within a year", milestone)
+		}
+		if !date.After(time.Time(last.Day)) {
+			t.Errorf("Date %v not after the history", date)
+		}
+		if earliest.After(date) || date.After(latest) {
+			t.Errorf("want Earliest %v <= Date %v <= Latest %v", earliest, date, latest)
+		}
+	})
+
+	t.Run("beyond horizon", func(t *testing.T) {
+		milestone, err := EstimateMilestoneDate(history, last.TotalStars*100, 30, 0.8)
+		if err != nil {
+			t.Fatalf("EstimateMilestoneDate() error = %v", err)
+		}
+
+		if want := (Milestone{Stars: last.TotalStars * 100}); milestone != want {
+			t.Errorf("milestone = %+v, want %+v", milestone, want)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package repostats
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+// returningContributorWindow is the time within which a first-time author opening another
+// pull request counts as returning.
+const returningContributorWindow = 90 * 24 * time.Hour
+
+// GetContributorFunnel returns, for each month from the one of the first pull request of a repo
+// to the one of the clock time, how many authors opened their first pull request, how many of
+// those were merged and how many authors came back with another pull request within 90 days,
+// so the last three months are still filling up. It also returns the share of the pull requests
+// merged in the month whose authors are not owners, members or collaborators, as they are now.
+// Unlike GetNewContributorsHistory, unmerged pull requests count as first attempts. Bots are left out.
+func (c *ClientGQL) GetContributorFunnel(ctx context.Context, ghRepo string, updateChannel chan<- int) ([]stats.ContributorFunnelMonth, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	type pr struct {
+		State             string
+		CreatedAt         time.Time
+		MergedAt          time.Time
+		AuthorAssociation string
+		Author            actor
+	}
+
+	type firstPR struct {
+		createdAt time.Time
+		returned  bool
+	}
+
+	now := c.opts.clock.Now()
+	authors := map[string]*firstPR{}
+	months := map[time.Time]*stats.ContributorFunnelMonth{}
+	var firstMonth time.Time
+
+	monthOf := func(t time.Time) *stats.ContributorFunnelMonth {
+		month := stats.Month.Start(t.In(c.opts.location))
+		if firstMonth.IsZero() || month.Before(firstMonth) {
+			firstMonth = month
+		}
+		if months[month] == nil {
+			months[month] = &stats.ContributorFunnelMonth{Month: stats.JSONDay(month)}
+		}
+		return months[month]
+	}
+
+	// pull requests come oldest first, so the first one of an author is seen first
+	err := paginatePullRequests(ctx, c, ghRepo, "GetContributorFunnel", &Counter{}, updateChannel, func(pr pr) {
+		login := strings.ToLower(pr.Author.Login)
+		if login == "" || pr.CreatedAt.After(now) || pr.Author.isBot() {
+			return
+		}
+
+		merged := pr.State == "MERGED" && !pr.MergedAt.IsZero() && !pr.MergedAt.After(now)
+
+		if merged {
+			month := monthOf(pr.MergedAt)
+			month.MergedPRs++
+			if !isMaintainer(pr.AuthorAssociation) {
+				month.ExternalMergedPRs++
+			}
+		}
+
+		first, seen := authors[login]
+		if !seen {
+			authors[login] = &firstPR{createdAt: pr.CreatedAt}
+			month := monthOf(pr.CreatedAt)
+			month.FirstTimeAuthors++
+			if merged {
+				month.FirstTimeMerged++
+			}
+			return
+		}
+
+		if !first.returned && pr.CreatedAt.Sub(first.createdAt) <= returningContributorWindow {
+			first.returned = true
+			monthOf(first.createdAt).Returned++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []stats.ContributorFunnelMonth{}
+	if firstMonth.IsZero() {
+		return result, nil
+	}
+
+	lastMonth := stats.Month.Start(now.In(c.opts.location))
+	for month := firstMonth; !month.After(lastMonth); month = month.AddDate(0, 1, 0) {
+		summary := stats.ContributorFunnelMonth{Month: stats.JSONDay(month)}
+		if m := months[month]; m != nil {
+			summary = *m
+		}
+		if summary.MergedPRs > 0 {
+			summary.ExternalMergedShare = float64(summary.ExternalMergedPRs) / float64(summary.MergedPRs)
+		}
+		result = append(result, summary)
+	}
+
+	return result, nil
+}
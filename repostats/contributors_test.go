@@ -0,0 +1,46 @@
+package repostats
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+func TestGetContributorFunnelSkipsBots(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := &ghfake.Repo{
+		Owner:     "octo",
+		Name:      "repo",
+		CreatedAt: march.AddDate(-1, 0, 0),
+		PullRequests: []ghfake.PullRequest{
+			{Number: 1, State: "MERGED", CreatedAt: march.AddDate(0, 0, 1), MergedAt: march.AddDate(0, 0, 2), ClosedAt: march.AddDate(0, 0, 2), Author: "dependabot[bot]", AuthorAssociation: "NONE"},
+			{Number: 2, State: "MERGED", CreatedAt: april.AddDate(0, 0, 3), MergedAt: april.AddDate(0, 0, 4), ClosedAt: april.AddDate(0, 0, 4), Author: "alice", AuthorAssociation: "CONTRIBUTOR"},
+			{Number: 3, State: "MERGED", CreatedAt: april.AddDate(0, 0, 5), MergedAt: april.AddDate(0, 0, 6), ClosedAt: april.AddDate(0, 0, 6), Author: "renovate[bot]", AuthorAssociation: "NONE"},
+			{Number: 4, State: "OPEN", CreatedAt: may.AddDate(0, 0, 2), Author: "alice", AuthorAssociation: "CONTRIBUTOR"},
+			{Number: 5, State: "CLOSED", CreatedAt: may.AddDate(0, 0, 3), ClosedAt: may.AddDate(0, 0, 4), Author: "bob", AuthorAssociation: "NONE"},
+		},
+	}
+
+	client, _ := newFakeClient(t, now, repo)
+
+	funnel, err := client.GetContributorFunnel(context.Background(), "octo/repo", nil)
+	if err != nil {
+		t.Fatalf("GetContributorFunnel() error = %v", err)
+	}
+
+	want := []stats.ContributorFunnelMonth{
+		{Month: stats.JSONDay(april), FirstTimeAuthors: 1, FirstTimeMerged: 1, Returned: 1, MergedPRs: 1, ExternalMergedPRs: 1, ExternalMergedShare: 1},
+		{Month: stats.JSONDay(may), FirstTimeAuthors: 1},
+	}
+	if !reflect.DeepEqual(funnel, want) {
+		t.Errorf("funnel = %+v, want %+v", funnel, want)
+	}
+}
@@ -0,0 +1,132 @@
+package repostats
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrInvalidRepo is returned when a repo is not provided as owner/name.
+	ErrInvalidRepo = errors.New("repo should be provided as owner/name")
+	// ErrRepoNotFound is returned when the repo doesn't exist or is not visible with the token used.
+	ErrRepoNotFound = errors.New("repository not found")
+	// ErrRefNotFound is returned when a branch, tag or commit doesn't exist in the repo.
+	ErrRefNotFound = errors.New("ref not found")
+	// ErrRateLimited matches any RateLimitError.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrPartialResult matches any PartialResultError.
+	ErrPartialResult = errors.New("partial result")
+	// ErrSearchCapReached is returned when GitHub search stops before all the matching results,
+	// as it never returns more than 1000 of them.
+	ErrSearchCapReached = errors.New("search result cap reached")
+)
+
+// searchResultsCap is the maximum number of results GitHub search returns for a query.
+const searchResultsCap = 1000
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// PartialResultError is returned together with the data fetched before Err interrupted the fetch.
+type PartialResultError struct {
+	Err error
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("partial result: %v", e.Err)
+}
+
+func (e *PartialResultError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PartialResultError) Is(target error) bool {
+	return target == ErrPartialResult
+}
+
+// SearchCapError is returned with the results of a search matching more items than GitHub returns.
+type SearchCapError struct {
+	Total    int
+	Returned int
+}
+
+func (e *SearchCapError) Error() string {
+	return fmt.Sprintf("search matched %d results, only %d returned", e.Total, e.Returned)
+}
+
+func (e *SearchCapError) Is(target error) bool {
+	return target == ErrSearchCapReached
+}
+
+// GraphQLError is an error reported by the GitHub GraphQL API in the errors array of a response.
+type GraphQLError struct {
+	Type    string
+	Message string
+	// Path is the field path the error refers to, e.g. ["repository", "stargazers"].
+	Path []string
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Path) > 0 {
+		return fmt.Sprintf("graphql %s error at %s: %s", e.Type, strings.Join(e.Path, "."), e.Message)
+	}
+	return fmt.Sprintf("graphql %s error: %s", e.Type, e.Message)
+}
+
+// Is reports NOT_FOUND errors on the repository field as ErrRepoNotFound.
+func (e *GraphQLError) Is(target error) bool {
+	return target == ErrRepoNotFound && e.Type == "NOT_FOUND" && len(e.Path) > 0 && e.Path[0] == "repository"
+}
+
+func newGraphQLError(respErr gqlResponseError) *GraphQLError {
+	path := make([]string, 0, len(respErr.Path))
+	for _, p := range respErr.Path {
+		switch v := p.(type) {
+		case string:
+			path = append(path, v)
+		case float64:
+			path = append(path, strconv.Itoa(int(v)))
+		}
+	}
+	return &GraphQLError{Type: respErr.Type, Message: respErr.Message, Path: path}
+}
+
+func invalidRepoError(ghRepo string) error {
+	return fmt.Errorf("%w: %q", ErrInvalidRepo, ghRepo)
+}
+
+// partialResult marks err as interrupting a fetch whose data is returned anyway.
+func partialResult(err error) error {
+	if err == nil || errors.Is(err, ErrPartialResult) {
+		return err
+	}
+	return &PartialResultError{Err: err}
+}
+
+// restError converts an unsuccessful REST response into one of the package errors.
+func restError(resp *resty.Response, ghRepo string) error {
+	switch resp.StatusCode() {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrRepoNotFound, ghRepo)
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		header := resp.Header()
+		err := fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode(), resp.String())
+		if seconds, convErr := strconv.Atoi(header.Get("Retry-After")); convErr == nil {
+			return &RateLimitError{Secondary: true, RetryAfter: time.Duration(seconds) * time.Second, Err: err}
+		}
+		if header.Get("X-RateLimit-Remaining") == "0" {
+			reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+			return &RateLimitError{ResetAt: time.Unix(reset, 0), Err: err}
+		}
+		return err
+	default:
+		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode(), resp.String())
+	}
+}
@@ -0,0 +1,462 @@
+package repostats
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultEstimatedStarsRequests is the requests budget used by GetStarsHistoryEstimated
+	// when none is given.
+	defaultEstimatedStarsRequests = 50
+
+	// stargazersPagesLimit is the last page served by the REST stargazers endpoint.
+	stargazersPagesLimit = 400
+
+	// estimatedSamplesConcurrency is the number of samples fetched at the same time, more
+	// would trigger the secondary rate limits.
+	estimatedSamplesConcurrency = 4
+)
+
+// sampledStar is a stargazer whose position, starting from 1 for the oldest star,
+// is known.
+type sampledStar struct {
+	Index     int
+	StarredAt time.Time
+}
+
+// GetStarsHistoryEstimated returns the stars history of a repo making at most maxRequests
+// requests, or defaultEstimatedStarsRequests if maxRequests is not positive.
+//
+// When the whole history doesn't fit the budget, the oldest and the newest stargazers are
+// fetched with GraphQL and a few pages evenly spread in the middle are sampled, with the REST
+// stargazers endpoint up to its 400 pages limit and with offset cursors of the GraphQL
+// stargazers connection after it. TotalStars is interpolated linearly between sampled
+// stars, and the days whose values were interpolated are marked as Estimated.
+// Samples are fetched a few at a time, applying the rate limit policy, and the ones that
+// still fail are left out of the estimate and reported in FailedSamples.
+func (c *ClientGQL) GetStarsHistoryEstimated(ctx context.Context, ghRepo string, maxRequests int, updateChannel chan<- int) (stats.EstimatedStarsHistory, error) {
+	result := stats.EstimatedStarsHistory{Timeline: []stats.EstimatedStarsPerDay{}, FailedSamples: []int{}}
+
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return result, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	if maxRequests <= 0 {
+		maxRequests = defaultEstimatedStarsRequests
+	}
+
+	owner := repoSplit[0]
+	name := repoSplit[1]
+
+	totalStars, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
+	if err != nil {
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetStarsHistoryEstimated", "error", err)
+		return result, err
+	}
+
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+
+	totalPages := int(math.Ceil(float64(totalStars) / 100))
+
+	headPages, tailPages, samplePages := totalPages, 0, 0
+	if totalPages > maxRequests {
+		samplePages = maxRequests / 4
+		tailPages = int(math.Ceil(float64(maxRequests-samplePages) / 2))
+		headPages = maxRequests - samplePages - tailPages
+	}
+
+	// sampled pages are between the ones reached by the forward and backward fetches
+	lastSamplePage := totalPages - tailPages
+	samplePages = max(0, min(samplePages, lastSamplePage-headPages))
+
+	c.opts.logger.Debug("fetching stars", "repo", ghRepo, "operation", "GetStarsHistoryEstimated",
+		"totalStars", totalStars, "forwardPages", headPages, "backwardPages", tailPages, "samplePages", samplePages)
+
+	counter := &Counter{}
+	samples := map[int]time.Time{}
+	var samplesMutex sync.Mutex
+
+	addSamples := func(stars []sampledStar) {
+		samplesMutex.Lock()
+		defer samplesMutex.Unlock()
+		for _, star := range stars {
+			samples[star.Index] = star.StarredAt
+		}
+	}
+
+	notify := func() {
+		counter.Increment()
+		if updateChannel != nil {
+			updateChannel <- counter.Value()
+		}
+	}
+
+	type starred struct {
+		StarredAt time.Time
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		variablesStars := map[string]any{
+			"owner":       githubv4.String(owner),
+			"name":        githubv4.String(name),
+			"starsCursor": (*githubv4.String)(nil),
+		}
+
+		var queryStars struct {
+			Repository struct {
+				Stargazers struct {
+					Edges    []starred
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"stargazers(first: 100, after: $starsCursor)"`
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		}
+
+		fetched := 0
+		for i := 0; i < headPages; i++ {
+			err := c.query(ctx, &queryStars, variablesStars)
+			if err != nil {
+				c.opts.logger.Error("forward query failed", "repo", ghRepo, "operation", "GetStarsHistoryEstimated", "page", i, "error", err)
+				return err
+			}
+
+			notify()
+
+			res := queryStars.Repository.Stargazers.Edges
+			stars := make([]sampledStar, 0, len(res))
+			for _, star := range res {
+				fetched++
+				stars = append(stars, sampledStar{Index: fetched, StarredAt: star.StarredAt})
+			}
+			addSamples(stars)
+
+			if len(res) == 0 || !queryStars.Repository.Stargazers.PageInfo.HasNextPage {
+				break
+			}
+
+			variablesStars["starsCursor"] = githubv4.NewString(queryStars.Repository.Stargazers.PageInfo.EndCursor)
+		}
+		return nil
+	})
+
+	eg.Go(func() error {
+		variablesStars := map[string]any{
+			"owner":       githubv4.String(owner),
+			"name":        githubv4.String(name),
+			"starsCursor": (*githubv4.String)(nil),
+		}
+
+		var queryStars struct {
+			Repository struct {
+				Stargazers struct {
+					Edges    []starred
+					PageInfo struct {
+						StartCursor     githubv4.String
+						HasPreviousPage bool
+					}
+				} `graphql:"stargazers(last: 100, before: $starsCursor)"`
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		}
+
+		fetched := 0
+		for i := 0; i < tailPages; i++ {
+			err := c.query(ctx, &queryStars, variablesStars)
+			if err != nil {
+				c.opts.logger.Error("backward query failed", "repo", ghRepo, "operation", "GetStarsHistoryEstimated", "page", i, "error", err)
+				return err
+			}
+
+			notify()
+
+			res := queryStars.Repository.Stargazers.Edges
+			stars := make([]sampledStar, 0, len(res))
+			for j := len(res) - 1; j >= 0; j-- {
+				stars = append(stars, sampledStar{Index: totalStars - fetched, StarredAt: res[j].StarredAt})
+				fetched++
+			}
+			addSamples(stars)
+
+			if len(res) == 0 || !queryStars.Repository.Stargazers.PageInfo.HasPreviousPage {
+				break
+			}
+
+			variablesStars["starsCursor"] = githubv4.NewString(queryStars.Repository.Stargazers.PageInfo.StartCursor)
+		}
+		return nil
+	})
+
+	var samplers errgroup.Group
+	samplers.SetLimit(estimatedSamplesConcurrency)
+
+	// pages evenly spread between the last forward page and the last backward one
+	for i := 1; i <= samplePages; i++ {
+		page := headPages + int(math.Round(float64(i*(lastSamplePage-headPages))/float64(samplePages+1)))
+		if samplePages == lastSamplePage-headPages {
+			page = headPages + i
+		}
+
+		sample := c.sampleStargazersPage
+		if page > stargazersPagesLimit {
+			sample = c.sampleStargazersCursor
+		}
+
+		samplers.Go(func() error {
+			stars, err := sample(ctx, ghRepo, page)
+			notify()
+			if err != nil {
+				// a missing sample only makes the estimate coarser
+				c.opts.logger.Warn("sample failed", "repo", ghRepo, "operation", "GetStarsHistoryEstimated", "page", page, "error", err)
+				samplesMutex.Lock()
+				result.FailedSamples = append(result.FailedSamples, (page-1)*100+1)
+				samplesMutex.Unlock()
+				return nil
+			}
+			addSamples(stars)
+			return nil
+		})
+	}
+
+	samplers.Wait()
+
+	if err := eg.Wait(); err != nil {
+		c.opts.logger.Error("fetch failed", "repo", ghRepo, "operation", "GetStarsHistoryEstimated", "error", err)
+		return result, partialResult(err)
+	}
+
+	slices.Sort(result.FailedSamples)
+
+	sampled := make([]sampledStar, 0, len(samples))
+	for index, starredAt := range samples {
+		if index >= 1 && index <= totalStars {
+			sampled = append(sampled, sampledStar{Index: index, StarredAt: starredAt})
+		}
+	}
+	slices.SortFunc(sampled, func(a, b sampledStar) int { return a.Index - b.Index })
+
+	// stars unstarred between requests can shift the positions, samples going back in time are dropped
+	monotonic := sampled[:0]
+	for _, star := range sampled {
+		if len(monotonic) > 0 && star.StarredAt.Before(monotonic[len(monotonic)-1].StarredAt) {
+			continue
+		}
+		monotonic = append(monotonic, star)
+	}
+
+	estimator := starsEstimator{samples: monotonic, start: repoCreationDate, end: now, total: totalStars}
+
+	days := max(0, c.opts.daysBetween(repoCreationDate, now)+1)
+	result.Timeline = make([]stats.EstimatedStarsPerDay, 0, days)
+
+	previous, previousEstimated := 0, false
+	for i := 0; i < days; i++ {
+		day := repoCreationDate.AddDate(0, 0, i)
+
+		endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		if endOfDay.After(now) {
+			endOfDay = now
+		}
+
+		total, estimated := estimator.starsAt(endOfDay)
+		result.Timeline = append(result.Timeline, stats.EstimatedStarsPerDay{
+			StarsPerDay: stats.StarsPerDay{
+				Day:        stats.JSONDay(day),
+				Stars:      total - previous,
+				TotalStars: total,
+			},
+			Estimated: estimated || previousEstimated,
+		})
+
+		previous, previousEstimated = total, estimated
+	}
+
+	return result, nil
+}
+
+// sampleStargazersPage returns the stars of a page of the REST stargazers endpoint.
+func (c *ClientGQL) sampleStargazersPage(ctx context.Context, ghRepo string, page int) ([]sampledStar, error) {
+	var res []struct {
+		StarredAt time.Time `json:"starred_at"`
+	}
+
+	err := c.retryRateLimited(ctx, "GetStarsHistoryEstimated", func() error {
+		resp, err := c.apiClient.R().
+			SetContext(ctx).
+			SetResult(&res).
+			SetHeader("Accept", "application/vnd.github.star+json").
+			SetQueryParams(map[string]string{
+				"page":     strconv.Itoa(page),
+				"per_page": "100",
+			}).
+			Get(fmt.Sprintf("%s/repos/%s/stargazers", c.opts.restURL, ghRepo))
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode() == http.StatusUnprocessableEntity {
+			return fmt.Errorf("stargazers page %d is over the %d pages limit", page, stargazersPagesLimit)
+		}
+
+		if !resp.IsSuccess() {
+			return restError(resp, ghRepo)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stars := make([]sampledStar, 0, len(res))
+	for i, star := range res {
+		if star.StarredAt.IsZero() {
+			continue
+		}
+		stars = append(stars, sampledStar{Index: (page-1)*100 + i + 1, StarredAt: star.StarredAt})
+	}
+
+	return stars, nil
+}
+
+// sampleStargazersCursor returns the stars of a page of 100 stargazers, like
+// sampleStargazersPage, through the GraphQL stargazers connection, for the pages after the
+// REST limit. The page starts after an offset cursor built by offsetCursor. Cursors being
+// opaque, the positions of the stars are read back from the cursors of the returned edges,
+// so that a connection not paging by offset makes the sample fail instead of misplacing it.
+func (c *ClientGQL) sampleStargazersCursor(ctx context.Context, ghRepo string, page int) ([]sampledStar, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	variables := map[string]any{
+		"owner":       githubv4.String(repoSplit[0]),
+		"name":        githubv4.String(repoSplit[1]),
+		"starsCursor": githubv4.String(offsetCursor((page-1)*100 - 1)),
+	}
+
+	var query struct {
+		Repository struct {
+			Stargazers struct {
+				Edges []struct {
+					StarredAt time.Time
+					Cursor    string
+				}
+			} `graphql:"stargazers(first: 100, after: $starsCursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	if err := c.query(ctx, &query, variables); err != nil {
+		return nil, err
+	}
+
+	edges := query.Repository.Stargazers.Edges
+	stars := make([]sampledStar, 0, len(edges))
+	for _, edge := range edges {
+		offset, ok := cursorOffset(edge.Cursor)
+		if !ok {
+			return nil, fmt.Errorf("stargazers cursor %q is not an offset", edge.Cursor)
+		}
+		stars = append(stars, sampledStar{Index: offset + 1, StarredAt: edge.StarredAt})
+	}
+
+	return stars, nil
+}
+
+// offsetCursor returns the cursor of the node at offset, from 0, of a connection paging by offset.
+func offsetCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:v2:" + strconv.Itoa(offset)))
+}
+
+// cursorOffset returns the offset of a cursor built like offsetCursor, false for other cursors.
+func cursorOffset(cursor string) (int, bool) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+
+	offset, found := strings.CutPrefix(string(data), "cursor:v2:")
+	if !found {
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(offset)
+	if err != nil || i < 0 {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// starsEstimator counts the stars given before a time from sampled stargazers,
+// interpolating between the samples that aren't contiguous.
+type starsEstimator struct {
+	// samples sorted by index, and so by time
+	samples []sampledStar
+	start   time.Time
+	end     time.Time
+	total   int
+}
+
+// starsAt returns the number of stars given up to t, and whether it was interpolated.
+func (e starsEstimator) starsAt(t time.Time) (int, bool) {
+	// first sample starred after t
+	next := sort.Search(len(e.samples), func(i int) bool {
+		return e.samples[i].StarredAt.After(t)
+	})
+
+	lowIndex, lowTime := 0, e.start
+	if next > 0 {
+		lowIndex, lowTime = e.samples[next-1].Index, e.samples[next-1].StarredAt
+	}
+
+	if next == len(e.samples) {
+		if lowIndex == e.total {
+			return lowIndex, false
+		}
+		return interpolateStars(t, lowTime, e.end, lowIndex, e.total), true
+	}
+
+	highIndex, highTime := e.samples[next].Index, e.samples[next].StarredAt
+	if highIndex == lowIndex+1 {
+		return lowIndex, false
+	}
+
+	return interpolateStars(t, lowTime, highTime, lowIndex, highIndex-1), true
+}
+
+// interpolateStars interpolates linearly between (fromTime, from) and (toTime, to).
+func interpolateStars(t, fromTime, toTime time.Time, from, to int) int {
+	if !toTime.After(fromTime) || !t.After(fromTime) {
+		return from
+	}
+	if !t.Before(toTime) {
+		return to
+	}
+
+	ratio := float64(t.Sub(fromTime)) / float64(toTime.Sub(fromTime))
+	return from + int(math.Round(ratio*float64(to-from)))
+}
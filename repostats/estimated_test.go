@@ -0,0 +1,169 @@
+package repostats
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+)
+
+// samplesTransport delays the REST stargazers requests to make them overlap, recording
+// how many ran at the same time, and fails some pages.
+type samplesTransport struct {
+	base http.RoundTripper
+	// rateLimited pages fail once with a secondary rate limit, failing ones always fail
+	rateLimited, failing int
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	requests    map[int]int
+}
+
+func (t *samplesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, "/stargazers") {
+		return t.base.RoundTrip(req)
+	}
+
+	page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+
+	t.mu.Lock()
+	t.inFlight++
+	t.maxInFlight = max(t.maxInFlight, t.inFlight)
+	t.requests[page]++
+	attempt := t.requests[page]
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	fail := func(status int, header http.Header) *http.Response {
+		header.Set("Content-Type", "application/json")
+		return &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader(`{"message": "failed"}`)),
+			Request:    req,
+		}
+	}
+
+	switch {
+	case page == t.failing:
+		return fail(http.StatusInternalServerError, http.Header{}), nil
+	case page == t.rateLimited && attempt == 1:
+		return fail(http.StatusForbidden, http.Header{"Retry-After": []string{"0"}}), nil
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+func TestGetStarsHistoryEstimatedSamples(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	created := now.AddDate(-2, 0, 0)
+
+	srv := ghfake.NewServer(&ghfake.Repo{
+		Owner:      "octo",
+		Name:       "repo",
+		CreatedAt:  created,
+		Stargazers: ghfake.GenerateStargazers(5000, created.Add(time.Hour), now.Add(-time.Hour)),
+	})
+	t.Cleanup(srv.Close)
+
+	// 40 requests for 50 pages: 15 forward, 15 backward and 10 samples from page 17 to 33
+	transport := &samplesTransport{base: srv.Client().Transport, rateLimited: 19, failing: 17, requests: map[int]int{}}
+	httpClient := srv.Client()
+	httpClient.Transport = transport
+
+	policy := DefaultRateLimitPolicy
+	policy.BaseBackoff = time.Millisecond
+	client := NewClientGQL(httpClient, WithEnterpriseServer(srv.URL), WithClock(FixedClock(now)), WithRateLimitPolicy(policy))
+
+	got, err := client.GetStarsHistoryEstimated(context.Background(), "octo/repo", 40, nil)
+	if err != nil {
+		t.Fatalf("GetStarsHistoryEstimated() error = %v", err)
+	}
+
+	if len(transport.requests) != 10 {
+		t.Errorf("sampled %d pages, want 10", len(transport.requests))
+	}
+	if transport.maxInFlight > estimatedSamplesConcurrency {
+		t.Errorf("%d samples fetched at the same time, want at most %d", transport.maxInFlight, estimatedSamplesConcurrency)
+	}
+	if requests := transport.requests[transport.rateLimited]; requests != 2 {
+		t.Errorf("rate limited page requested %d times, want 2", requests)
+	}
+
+	if want := []int{1601}; !reflect.DeepEqual(got.FailedSamples, want) {
+		t.Errorf("FailedSamples = %v, want %v", got.FailedSamples, want)
+	}
+
+	if total := got.Timeline[len(got.Timeline)-1].TotalStars; total != 5000 {
+		t.Errorf("TotalStars = %d, want 5000", total)
+	}
+}
+
+func TestGetStarsHistoryEstimatedBeyondRESTLimit(t *testing.T) {
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return created.AddDate(0, 0, i) }
+	now := day(500)
+
+	// 600 pages: 400 over a year, 185 in a burst of a week, then 15 in the following months
+	spread := func(n int, from, to time.Time) []time.Time {
+		times := make([]time.Time, n)
+		for i := range times {
+			times[i] = from.Add(time.Duration(i) * to.Sub(from) / time.Duration(n))
+		}
+		return times
+	}
+	var times []time.Time
+	times = append(times, spread(40_000, day(1), day(400))...)
+	times = append(times, spread(18_500, day(401), day(408))...)
+	times = append(times, spread(1_500, day(408), day(499))...)
+
+	client, srv := newFakeClient(t, now, &ghfake.Repo{Owner: "octo", Name: "repo", CreatedAt: created, Stargazers: stargazersAt(times...)})
+
+	// 15 pages forward, 15 backward and 10 samples from page 67 to 533, 52 pages apart at most,
+	// the last three after the REST limit
+	got, err := client.GetStarsHistoryEstimated(context.Background(), "octo/repo", 40, nil)
+	if err != nil {
+		t.Fatalf("GetStarsHistoryEstimated() error = %v", err)
+	}
+
+	if len(got.FailedSamples) > 0 {
+		t.Errorf("FailedSamples = %v, want none", got.FailedSamples)
+	}
+	if requests := srv.RequestCount(); requests > 41 {
+		t.Errorf("sent %d requests, want at most 41", requests)
+	}
+
+	// an interpolated day is within the stars between the samples around it
+	const maxSampleGap = 52 * 100
+
+	starred := 0
+	for _, estimated := range got.Timeline {
+		endOfDay := time.Time(estimated.Day).AddDate(0, 0, 1)
+		for starred < len(times) && times[starred].Before(endOfDay) {
+			starred++
+		}
+
+		if diff := estimated.TotalStars - starred; diff > maxSampleGap || diff < -maxSampleGap {
+			t.Errorf("day %s: TotalStars = %d, want %d within %d", time.Time(estimated.Day).Format(time.DateOnly), estimated.TotalStars, starred, maxSampleGap)
+		}
+	}
+
+	if total := got.Timeline[len(got.Timeline)-1].TotalStars; total != len(times) {
+		t.Errorf("TotalStars = %d, want %d", total, len(times))
+	}
+}
@@ -0,0 +1,279 @@
+package ghfake
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// args are the arguments of a field, with variables already replaced by their values.
+type args map[string]any
+
+func (a args) int(name string) (int, bool) {
+	switch v := a[name].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+func (a args) string(name string) (string, bool) {
+	v, ok := a[name].(string)
+	return v, ok && v != ""
+}
+
+func (a args) time(name string) (time.Time, bool) {
+	v, ok := a.string(name)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, err == nil
+}
+
+// strings returns a list argument, a single value is returned as a list of one.
+func (a args) strings(name string) []string {
+	switch v := a[name].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// orderDescending reports whether orderBy asks for a descending direction.
+func (a args) orderDescending() bool {
+	orderBy, _ := a["orderBy"].(map[string]any)
+	return orderBy["direction"] == "DESC"
+}
+
+type resolver func(a args) (any, error)
+
+// object is a GraphQL object: resolvers return scalars, *object, []*object or nil.
+type object struct {
+	typeName string
+	fields   map[string]resolver
+}
+
+// fieldError is returned by resolvers to add an error to the response.
+type fieldError struct {
+	Type    string
+	Message string
+}
+
+func (e *fieldError) Error() string {
+	return e.Message
+}
+
+type responseError struct {
+	Type      string `json:"type,omitempty"`
+	Message   string `json:"message"`
+	Path      []any  `json:"path,omitempty"`
+	Locations []any  `json:"locations,omitempty"`
+}
+
+func value(v any) resolver {
+	return func(args) (any, error) {
+		return v, nil
+	}
+}
+
+func timeValue(t time.Time) resolver {
+	return func(args) (any, error) {
+		if t.IsZero() {
+			return nil, nil
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+}
+
+func count(n int) *object {
+	return &object{typeName: "Count", fields: map[string]resolver{"totalCount": value(n)}}
+}
+
+type executor struct {
+	variables map[string]any
+	errors    []responseError
+}
+
+func (e *executor) execute(obj *object, selections []*selection, path []any) map[string]any {
+	out := map[string]any{}
+	e.executeInto(out, obj, selections, path)
+	return out
+}
+
+func (e *executor) executeInto(out map[string]any, obj *object, selections []*selection, path []any) {
+	for _, sel := range selections {
+		if sel.typeCondition != "" {
+			if sel.typeCondition == obj.typeName {
+				e.executeInto(out, obj, sel.selections, path)
+			}
+			continue
+		}
+
+		fieldPath := append(append([]any{}, path...), sel.key())
+
+		if sel.name == "__typename" {
+			out[sel.key()] = obj.typeName
+			continue
+		}
+
+		resolve, ok := obj.fields[sel.name]
+		if !ok {
+			e.errors = append(e.errors, responseError{
+				Type:    "undefinedField",
+				Message: fmt.Sprintf("Field '%s' doesn't exist on type '%s'", sel.name, obj.typeName),
+				Path:    fieldPath,
+			})
+			out[sel.key()] = nil
+			continue
+		}
+
+		v, err := resolve(resolveArgs(sel.args, e.variables))
+		if err != nil {
+			respErr := responseError{Message: err.Error(), Path: fieldPath}
+			if fe, ok := err.(*fieldError); ok {
+				respErr.Type = fe.Type
+			}
+			e.errors = append(e.errors, respErr)
+			out[sel.key()] = nil
+			continue
+		}
+
+		out[sel.key()] = e.complete(v, sel, fieldPath)
+	}
+}
+
+func (e *executor) complete(v any, sel *selection, path []any) any {
+	switch v := v.(type) {
+	case *object:
+		if v == nil {
+			return nil
+		}
+		return e.execute(v, sel.selections, path)
+	case []*object:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = e.complete(item, sel, append(append([]any{}, path...), i))
+		}
+		return list
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// connectionItem is a node of a connection, edgeFields are the fields living on the edge,
+// like starredAt for stargazers.
+type connectionItem struct {
+	node       *object
+	edgeFields map[string]resolver
+	// build, if set, returns the item, only for the items of the requested page
+	build func() connectionItem
+}
+
+func encodeCursor(i int) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:v2:" + strconv.Itoa(i)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, &fieldError{Type: "INVALID_CURSOR_ARGUMENTS", Message: fmt.Sprintf("`%s` does not appear to be a valid cursor.", cursor)}
+	}
+	i, err := strconv.Atoi(strings.TrimPrefix(string(data), "cursor:v2:"))
+	if err != nil {
+		return 0, &fieldError{Type: "INVALID_CURSOR_ARGUMENTS", Message: fmt.Sprintf("`%s` does not appear to be a valid cursor.", cursor)}
+	}
+	return i, nil
+}
+
+// connection pages items following the first/after and last/before arguments.
+func connection(typeName string, items []connectionItem, a args) (*object, error) {
+	start, end := 0, len(items)
+
+	if after, ok := a.string("after"); ok {
+		i, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		start = min(i+1, len(items))
+	}
+
+	if before, ok := a.string("before"); ok {
+		i, err := decodeCursor(before)
+		if err != nil {
+			return nil, err
+		}
+		end = max(min(i, end), start)
+	}
+
+	first, hasFirst := a.int("first")
+	last, hasLast := a.int("last")
+
+	if hasFirst && first > 100 || hasLast && last > 100 {
+		return nil, &fieldError{Type: "EXCESSIVE_PAGINATION", Message: "Requesting more than 100 records on the connection is not allowed."}
+	}
+
+	if hasFirst {
+		end = min(end, start+first)
+	}
+
+	if hasLast {
+		start = max(start, end-last)
+	}
+
+	page := items[start:end]
+
+	edges := make([]*object, len(page))
+	nodes := make([]*object, len(page))
+	for i, item := range page {
+		if item.build != nil {
+			item = item.build()
+		}
+		fields := map[string]resolver{
+			"cursor": value(encodeCursor(start + i)),
+			"node":   value(item.node),
+		}
+		for name, r := range item.edgeFields {
+			fields[name] = r
+		}
+		edges[i] = &object{typeName: typeName + "Edge", fields: fields}
+		nodes[i] = item.node
+	}
+
+	var startCursor, endCursor any
+	if len(page) > 0 {
+		startCursor = encodeCursor(start)
+		endCursor = encodeCursor(end - 1)
+	}
+
+	pageInfo := &object{typeName: "PageInfo", fields: map[string]resolver{
+		"startCursor":     value(startCursor),
+		"endCursor":       value(endCursor),
+		"hasNextPage":     value(end < len(items)),
+		"hasPreviousPage": value(start > 0),
+	}}
+
+	return &object{typeName: typeName + "Connection", fields: map[string]resolver{
+		"totalCount": value(len(items)),
+		"edges":      value(edges),
+		"nodes":      value(nodes),
+		"pageInfo":   value(pageInfo),
+	}}, nil
+}
@@ -0,0 +1,203 @@
+package ghfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Repo is an in-memory repository served by Server.
+// Connections are returned in slice order, so Stargazers are expected sorted by StarredAt,
+// Issues, PullRequests, Forks and Releases by CreatedAt, and Commits from the newest.
+type Repo struct {
+	Owner            string            `json:"owner"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	PrimaryLanguage  string            `json:"primaryLanguage"`
+	DefaultBranch    string            `json:"defaultBranch"`
+	IsArchived       bool              `json:"isArchived"`
+	DiskUsage        int               `json:"diskUsage"`
+	MentionableUsers int               `json:"mentionableUsers"`
+	Stargazers       []Stargazer       `json:"stargazers"`
+	Issues           []Issue           `json:"issues"`
+	PullRequests     []PullRequest     `json:"pullRequests"`
+	Forks            []Fork            `json:"forks"`
+	Commits          []Commit          `json:"commits"`
+	Releases         []Release         `json:"releases"`
+	Refs             map[string]string `json:"refs"`  // ref name, e.g. "release-1.0" or "v1.0", to commit OID
+	Tags             map[string]string `json:"tags"`  // annotated tag name to commit OID, served as Tag objects
+	Files            map[string]string `json:"files"` // file contents on the default branch by path
+}
+
+// FullName returns owner/name.
+func (r *Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+type User struct {
+	Login         string    `json:"login"`
+	Name          string    `json:"name"`
+	Company       string    `json:"company"`
+	Location      string    `json:"location"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Followers     int       `json:"followers"`
+	Following     int       `json:"following"`
+	Repositories  int       `json:"repositories"`
+	Organizations []string  `json:"organizations"`
+	// StarredRepositories are owner/name of the repos starred by the user, oldest first.
+	StarredRepositories []string `json:"starredRepositories"`
+}
+
+type Stargazer struct {
+	User
+	StarredAt time.Time `json:"starredAt"`
+}
+
+type Comment struct {
+	Author            string    `json:"author"`
+	AuthorAssociation string    `json:"authorAssociation"`
+	CreatedAt         time.Time `json:"createdAt"`
+	Body              string    `json:"body"`
+}
+
+type LabelEvent struct {
+	Actor     string    `json:"actor"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Issue struct {
+	Number            int          `json:"number"`
+	Title             string       `json:"title"`
+	State             string       `json:"state"` // OPEN or CLOSED
+	CreatedAt         time.Time    `json:"createdAt"`
+	UpdatedAt         time.Time    `json:"updatedAt"`
+	ClosedAt          time.Time    `json:"closedAt"`
+	Author            string       `json:"author"`
+	AuthorAssociation string       `json:"authorAssociation"`
+	Labels            []string     `json:"labels"`
+	Milestone         string       `json:"milestone"`
+	Comments          []Comment    `json:"comments"`
+	LabelEvents       []LabelEvent `json:"labelEvents"`
+}
+
+type Review struct {
+	Author      string    `json:"author"`
+	State       string    `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+type PullRequest struct {
+	Number            int       `json:"number"`
+	Title             string    `json:"title"`
+	State             string    `json:"state"` // OPEN, CLOSED or MERGED
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+	ClosedAt          time.Time `json:"closedAt"`
+	MergedAt          time.Time `json:"mergedAt"`
+	Author            string    `json:"author"`
+	AuthorAssociation string    `json:"authorAssociation"`
+	Labels            []string  `json:"labels"`
+	Milestone         string    `json:"milestone"`
+	Additions         int       `json:"additions"`
+	Deletions         int       `json:"deletions"`
+	Comments          []Comment `json:"comments"`
+	Reviews           []Review  `json:"reviews"`
+}
+
+type Fork struct {
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Commit struct {
+	OID           string    `json:"oid"`
+	CommittedDate time.Time `json:"committedDate"`
+	AuthorName    string    `json:"authorName"`
+	AuthorEmail   string    `json:"authorEmail"`
+	AuthorLogin   string    `json:"authorLogin"`
+	Additions     int       `json:"additions"`
+	Deletions     int       `json:"deletions"`
+}
+
+type Release struct {
+	Name         string    `json:"name"`
+	TagName      string    `json:"tagName"`
+	CreatedAt    time.Time `json:"createdAt"`
+	PublishedAt  time.Time `json:"publishedAt"`
+	IsPrerelease bool      `json:"isPrerelease"`
+	IsDraft      bool      `json:"isDraft"`
+	Author       string    `json:"author"`
+	Assets       int       `json:"assets"`
+}
+
+// SearchResult is returned for a search query, Count can exceed len(Items)
+// to mimic the 1000 results cap of GitHub search.
+type SearchResult struct {
+	Count int          `json:"count"`
+	Items []SearchItem `json:"items"`
+}
+
+type SearchItem struct {
+	Type       string    `json:"type"` // Issue, PullRequest or Discussion
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	Repository string    `json:"repository"`
+	State      string    `json:"state"`
+	Author     string    `json:"author"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// LoadRepo reads a Repo fixture stored as JSON.
+func LoadRepo(path string) (*Repo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repo{}
+	if err := json.Unmarshal(data, repo); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return repo, nil
+}
+
+// GenerateStargazers returns n stargazers spread evenly from start to end.
+func GenerateStargazers(n int, start, end time.Time) []Stargazer {
+	stargazers := make([]Stargazer, n)
+	step := time.Duration(0)
+	if n > 1 {
+		step = end.Sub(start) / time.Duration(n-1)
+	}
+	for i := range stargazers {
+		starredAt := start.Add(time.Duration(i) * step)
+		stargazers[i] = Stargazer{
+			User:      User{Login: fmt.Sprintf("user%d", i), CreatedAt: starredAt.AddDate(-1, 0, 0)},
+			StarredAt: starredAt,
+		}
+	}
+	return stargazers
+}
+
+// GenerateCommits returns n commits spread evenly from start to end, newest first.
+func GenerateCommits(n int, start, end time.Time) []Commit {
+	commits := make([]Commit, n)
+	step := time.Duration(0)
+	if n > 1 {
+		step = end.Sub(start) / time.Duration(n-1)
+	}
+	for i := range commits {
+		commits[n-1-i] = Commit{
+			OID:           fmt.Sprintf("%040x", i+1),
+			CommittedDate: start.Add(time.Duration(i) * step),
+			AuthorName:    "Author",
+			AuthorEmail:   "author@example.com",
+		}
+	}
+	return commits
+}
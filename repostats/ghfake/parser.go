@@ -0,0 +1,379 @@
+package ghfake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The parser covers the subset of GraphQL produced by githubv4:
+// one anonymous or named query, variable definitions, fields with arguments,
+// aliases and inline fragments. Fragment spreads and directives are not supported.
+
+type selection struct {
+	alias         string
+	name          string
+	args          map[string]any
+	selections    []*selection
+	typeCondition string // set for inline fragments, which have no name
+}
+
+func (s *selection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// variable is an argument value referring to a query variable.
+type variable string
+
+// enumValue is an unquoted argument value like OPEN or CREATED_AT.
+type enumValue string
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenString
+	tokenNumber
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		ch := rune(src[i])
+		switch {
+		case unicode.IsSpace(ch) || ch == ',':
+			i++
+		case ch == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{tokenPunct, "..."})
+			i += 3
+		case strings.ContainsRune("{}()[]:$!=@", ch):
+			tokens = append(tokens, token{tokenPunct, string(ch)})
+			i++
+		case ch == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			value, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, value})
+			i = j + 1
+		case ch == '-' || unicode.IsDigit(ch):
+			j := i + 1
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.' || src[j] == 'e' || src[j] == 'E') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, src[i:j]})
+			i = j
+		case ch == '_' || unicode.IsLetter(ch):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{tokenName, src[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", ch, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// parseQuery returns the top level selections of a query document.
+func parseQuery(src string) ([]*selection, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	if p.peek().kind == tokenName {
+		if op := p.next().value; op != "query" {
+			return nil, fmt.Errorf("unsupported operation %q", op)
+		}
+		if p.peek().kind == tokenName {
+			p.next()
+		}
+		if p.peekPunct("(") {
+			if err := p.skipVariableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q after query", p.peek().value)
+	}
+
+	return selections, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) peekPunct(value string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.value == value
+}
+
+func (p *parser) expect(value string) error {
+	t := p.next()
+	if t.kind != tokenPunct || t.value != value {
+		return fmt.Errorf("expected %q, found %q", value, t.value)
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	t := p.next()
+	if t.kind != tokenName {
+		return "", fmt.Errorf("expected name, found %q", t.value)
+	}
+	return t.value, nil
+}
+
+// skipVariableDefinitions skips "($owner:String!$cursor:String)", the types are not checked.
+func (p *parser) skipVariableDefinitions() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	depth := 1
+	for depth > 0 {
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return fmt.Errorf("unterminated variable definitions")
+		case t.kind == tokenPunct && t.value == "(":
+			depth++
+		case t.kind == tokenPunct && t.value == ")":
+			depth--
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseSelectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []*selection
+	for !p.peekPunct("}") {
+		if p.peek().kind == tokenEOF {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.next()
+
+	return selections, nil
+}
+
+func (p *parser) parseSelection() (*selection, error) {
+	if p.peekPunct("...") {
+		p.next()
+		if on, err := p.expectName(); err != nil || on != "on" {
+			return nil, fmt.Errorf("only inline fragments are supported")
+		}
+		typeCondition, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		selections, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+		return &selection{typeCondition: typeCondition, selections: selections}, nil
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	sel := &selection{name: name}
+
+	if p.peekPunct(":") {
+		p.next()
+		sel.alias = name
+		if sel.name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peekPunct("(") {
+		if sel.args, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peekPunct("{") {
+		if sel.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sel, nil
+}
+
+func (p *parser) parseArguments() (map[string]any, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	args := map[string]any{}
+	for !p.peekPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	p.next()
+
+	return args, nil
+}
+
+func (p *parser) parseValue() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.value, nil
+	case tokenNumber:
+		if n, err := strconv.Atoi(t.value); err == nil {
+			return n, nil
+		}
+		return strconv.ParseFloat(t.value, 64)
+	case tokenName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(t.value), nil
+	case tokenPunct:
+		switch t.value {
+		case "$":
+			name, err := p.expectName()
+			return variable(name), err
+		case "[":
+			list := []any{}
+			for !p.peekPunct("]") {
+				if p.peek().kind == tokenEOF {
+					return nil, fmt.Errorf("unterminated list")
+				}
+				value, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			object := map[string]any{}
+			for !p.peekPunct("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				value, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				object[name] = value
+			}
+			p.next()
+			return object, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q in value", t.value)
+}
+
+// resolveArgs replaces variables with their values, enums become plain strings.
+func resolveArgs(args map[string]any, variables map[string]any) map[string]any {
+	resolved := make(map[string]any, len(args))
+	for name, value := range args {
+		resolved[name] = resolveValue(value, variables)
+	}
+	return resolved
+}
+
+func resolveValue(value any, variables map[string]any) any {
+	switch v := value.(type) {
+	case variable:
+		return variables[string(v)]
+	case enumValue:
+		return string(v)
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = resolveValue(item, variables)
+		}
+		return list
+	case map[string]any:
+		return resolveArgs(v, variables)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,165 @@
+package ghfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Interaction is a recorded request and its response.
+// Request headers are not stored, so tokens never end up in the recordings.
+type Interaction struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"requestBody,omitempty"`
+	StatusCode  int         `json:"statusCode"`
+	Header      http.Header `json:"header"`
+	Body        string      `json:"body"`
+}
+
+func (i *Interaction) key() string {
+	return i.Method + " " + i.URL + "\n" + i.RequestBody
+}
+
+// readBody reads and restores the body of a request.
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return string(body), err
+}
+
+// Recorder is an http.RoundTripper recording the interactions with the real API,
+// to be replayed later with a Replayer.
+type Recorder struct {
+	path string
+	base http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder returns a Recorder sending requests to base, or http.DefaultTransport if nil.
+// The interactions are written to path by Save.
+func NewRecorder(path string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{path: path, base: base}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, Interaction{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: requestBody,
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header.Clone(),
+		Body:        string(body),
+	})
+
+	return resp, nil
+}
+
+// Save writes the recorded interactions as JSON.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+// Replayer is an http.RoundTripper answering from interactions saved by a Recorder.
+// Requests are matched on method, URL and body. Identical requests get the recorded
+// responses in order, the last one being repeated once the others are used.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Interaction
+}
+
+// NewReplayer loads the interactions saved at path.
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	r := &Replayer{responses: map[string][]Interaction{}}
+	for _, interaction := range interactions {
+		key := interaction.key()
+		r.responses[key] = append(r.responses[key], interaction)
+	}
+
+	return r, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	key := (&Interaction{Method: req.Method, URL: req.URL.String(), RequestBody: requestBody}).key()
+
+	r.mu.Lock()
+	queue := r.responses[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("ghfake: no recorded response for %s %s", req.Method, req.URL)
+	}
+	interaction := queue[0]
+	if len(queue) > 1 {
+		r.responses[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewBufferString(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
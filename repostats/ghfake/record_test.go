@@ -0,0 +1,89 @@
+package ghfake_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/repostats"
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+)
+
+func TestRecordReplay(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	created := now.AddDate(0, -6, 0)
+	path := filepath.Join(t.TempDir(), "interactions.json")
+
+	srv := ghfake.NewServer(&ghfake.Repo{
+		Owner:         "octo",
+		Name:          "repo",
+		CreatedAt:     created,
+		DefaultBranch: "main",
+		Stargazers:    ghfake.GenerateStargazers(1234, created.Add(time.Hour), now),
+	})
+
+	fetch := func(transport http.RoundTripper) ([]any, error) {
+		gql := repostats.NewClientGQL(&http.Client{Transport: transport},
+			repostats.WithEnterpriseServer(srv.URL), repostats.WithClock(repostats.FixedClock(now)))
+		rest := repostats.NewClient(&transport,
+			repostats.WithEnterpriseServer(srv.URL), repostats.WithClock(repostats.FixedClock(now)))
+
+		history, err := gql.GetAllStarsHistoryTwoWays(context.Background(), "octo/repo", nil)
+		if err != nil {
+			return nil, err
+		}
+		repoStats, err := rest.GetAllStats("octo/repo")
+		if err != nil {
+			return nil, err
+		}
+		return []any{history, repoStats}, nil
+	}
+
+	recorder := ghfake.NewRecorder(path, nil)
+	recorded, err := fetch(recorder)
+	if err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	requests := srv.RequestCount()
+	srv.Close()
+
+	replayer, err := ghfake.NewReplayer(path)
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+	replayed, err := fetch(replayer)
+	if err != nil {
+		t.Fatalf("replaying: %v", err)
+	}
+
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Errorf("replayed results differ from the recorded ones")
+	}
+	if got := srv.RequestCount(); got != requests {
+		t.Errorf("server got %d requests while replaying", got-requests)
+	}
+}
+
+func TestReplayerUnknownRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "interactions.json")
+	if err := ghfake.NewRecorder(path, nil).Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	replayer, err := ghfake.NewReplayer(path)
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/repos/octo/repo", nil)
+	if _, err := replayer.RoundTrip(req); err == nil {
+		t.Error("RoundTrip() of a request not recorded: got no error")
+	}
+}
@@ -0,0 +1,499 @@
+package ghfake
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// queryRoot returns the root object of a GraphQL query sent with token.
+func (s *Server) queryRoot(token string) *object {
+	return &object{typeName: "Query", fields: map[string]resolver{
+		"repository": func(a args) (any, error) {
+			owner, _ := a.string("owner")
+			name, _ := a.string("name")
+			repo := s.repo(owner + "/" + name)
+			if repo == nil {
+				return nil, &fieldError{
+					Type:    "NOT_FOUND",
+					Message: fmt.Sprintf("Could not resolve to a Repository with the name '%s/%s'.", owner, name),
+				}
+			}
+			return s.repositoryObject(repo), nil
+		},
+		"user": func(a args) (any, error) {
+			login, _ := a.string("login")
+			user, ok := s.user(login)
+			if !ok {
+				return nil, &fieldError{
+					Type:    "NOT_FOUND",
+					Message: fmt.Sprintf("Could not resolve to a User with the login of '%s'.", login),
+				}
+			}
+			return s.userObject(user), nil
+		},
+		"rateLimit": func(args) (any, error) {
+			b := s.rateLimit(token)
+			return &object{typeName: "RateLimit", fields: map[string]resolver{
+				"limit":     value(b.limit),
+				"cost":      value(1),
+				"remaining": value(b.remaining),
+				"used":      value(b.limit - b.remaining),
+				"resetAt":   timeValue(b.resetAt),
+			}}, nil
+		},
+		"search": func(a args) (any, error) {
+			searchType, _ := a.string("type")
+			query, _ := a.string("query")
+			result := s.search(searchType, query)
+
+			items := make([]connectionItem, len(result.Items))
+			for i, item := range result.Items {
+				items[i] = connectionItem{node: s.searchItemObject(item)}
+			}
+
+			conn, err := connection("SearchResultItem", items, a)
+			if err != nil {
+				return nil, err
+			}
+			conn.fields["issueCount"] = value(result.Count)
+			conn.fields["repositoryCount"] = value(result.Count)
+			conn.fields["discussionCount"] = value(result.Count)
+			return conn, nil
+		},
+	}}
+}
+
+func (s *Server) repositoryObject(r *Repo) *object {
+	fields := map[string]resolver{
+		"id":             value("R_" + r.FullName()),
+		"name":           value(r.Name),
+		"nameWithOwner":  value(r.FullName()),
+		"url":            value(s.URL + "/" + r.FullName()),
+		"description":    value(r.Description),
+		"stargazerCount": value(len(r.Stargazers)),
+		"forkCount":      value(len(r.Forks)),
+		"createdAt":      timeValue(r.CreatedAt),
+		"isArchived":     value(r.IsArchived),
+		"diskUsage":      value(r.DiskUsage),
+		"owner": value(&object{typeName: "User", fields: map[string]resolver{
+			"login": value(r.Owner),
+		}}),
+		"primaryLanguage": func(args) (any, error) {
+			if r.PrimaryLanguage == "" {
+				return nil, nil
+			}
+			return &object{typeName: "Language", fields: map[string]resolver{"name": value(r.PrimaryLanguage)}}, nil
+		},
+		"mentionableUsers": value(count(r.MentionableUsers)),
+		"stargazers": func(a args) (any, error) {
+			// large repos have many stargazers, only the ones of the page are built
+			items := make([]connectionItem, len(r.Stargazers))
+			for i := range r.Stargazers {
+				items[i].build = func() connectionItem {
+					return connectionItem{
+						node:       s.userObject(r.Stargazers[i].User),
+						edgeFields: map[string]resolver{"starredAt": timeValue(r.Stargazers[i].StarredAt)},
+					}
+				}
+			}
+			return connection("Stargazer", items, a)
+		},
+		"issues": func(a args) (any, error) {
+			states := a.strings("states")
+			var items []connectionItem
+			for _, issue := range r.Issues {
+				if len(states) == 0 || slices.Contains(states, issue.State) {
+					items = append(items, connectionItem{node: s.issueObject(r, issue)})
+				}
+			}
+			return connection("Issue", ordered(items, a.orderDescending()), a)
+		},
+		"pullRequests": func(a args) (any, error) {
+			states := a.strings("states")
+			var items []connectionItem
+			for _, pr := range r.PullRequests {
+				if len(states) == 0 || slices.Contains(states, pr.State) {
+					items = append(items, connectionItem{node: s.pullRequestObject(r, pr)})
+				}
+			}
+			return connection("PullRequest", ordered(items, a.orderDescending()), a)
+		},
+		"forks": func(a args) (any, error) {
+			items := make([]connectionItem, len(r.Forks))
+			for i, fork := range r.Forks {
+				items[i] = connectionItem{node: &object{typeName: "Repository", fields: map[string]resolver{
+					"nameWithOwner": value(fork.Owner + "/" + r.Name),
+					"createdAt":     timeValue(fork.CreatedAt),
+					"owner":         value(&object{typeName: "User", fields: map[string]resolver{"login": value(fork.Owner)}}),
+				}}}
+			}
+			return connection("Repository", ordered(items, a.orderDescending()), a)
+		},
+		"releases": func(a args) (any, error) {
+			items := make([]connectionItem, len(r.Releases))
+			for i, release := range r.Releases {
+				items[i] = connectionItem{node: s.releaseObject(r, release)}
+			}
+			// GitHub lists the newest releases first when no order is given
+			_, hasOrder := a["orderBy"]
+			return connection("Release", ordered(items, !hasOrder || a.orderDescending()), a)
+		},
+		"defaultBranchRef": func(args) (any, error) {
+			if r.DefaultBranch == "" {
+				return nil, nil
+			}
+			return s.refObject(r, r.DefaultBranch, 0), nil
+		},
+		"ref": func(a args) (any, error) {
+			qualifiedName, _ := a.string("qualifiedName")
+			name := strings.TrimPrefix(strings.TrimPrefix(qualifiedName, "refs/heads/"), "refs/tags/")
+			i, ok := r.commitIndex(name)
+			if !ok {
+				return nil, nil
+			}
+			return s.refObject(r, name, i), nil
+		},
+		"object": func(a args) (any, error) {
+			expression, _ := a.string("expression")
+
+			// rev:path expressions resolve to the files of the default branch
+			if rev, path, ok := strings.Cut(expression, ":"); ok {
+				if _, ok := r.commitIndex(rev); !ok {
+					return nil, nil
+				}
+				return r.fileObject(path), nil
+			}
+
+			if oid, ok := r.Tags[expression]; ok {
+				i, ok := r.commitIndex(oid)
+				if !ok {
+					return nil, nil
+				}
+				return &object{typeName: "Tag", fields: map[string]resolver{
+					"name":   value(expression),
+					"target": value(s.commitObject(r, i)),
+				}}, nil
+			}
+
+			i, ok := r.commitIndex(expression)
+			if !ok {
+				return nil, nil
+			}
+			return s.commitObject(r, i), nil
+		},
+	}
+
+	return &object{typeName: "Repository", fields: fields}
+}
+
+// ordered returns items in reverse order when descending is set.
+func ordered(items []connectionItem, descending bool) []connectionItem {
+	if descending {
+		items = slices.Clone(items)
+		slices.Reverse(items)
+	}
+	return items
+}
+
+// commitIndex returns the index in Commits of a branch, tag or commit OID.
+func (r *Repo) commitIndex(name string) (int, bool) {
+	if len(r.Commits) == 0 {
+		return 0, false
+	}
+
+	if name == r.DefaultBranch || name == "HEAD" {
+		return 0, true
+	}
+
+	oid := name
+	if refOID, ok := r.Refs[name]; ok {
+		oid = refOID
+	}
+
+	for i, commit := range r.Commits {
+		if commit.OID == oid {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// fileObject returns the Blob of a file in Files, or the Tree of a directory containing some.
+func (r *Repo) fileObject(path string) *object {
+	if _, ok := r.Files[path]; ok {
+		return &object{typeName: "Blob", fields: map[string]resolver{"text": value(r.Files[path])}}
+	}
+
+	dir := strings.TrimSuffix(path, "/")
+	for name := range r.Files {
+		if dir == "" || strings.HasPrefix(name, dir+"/") {
+			return &object{typeName: "Tree", fields: map[string]resolver{}}
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) refObject(r *Repo, name string, head int) *object {
+	return &object{typeName: "Ref", fields: map[string]resolver{
+		"name": value(name),
+		"target": func(args) (any, error) {
+			if head >= len(r.Commits) {
+				return nil, nil
+			}
+			return s.commitObject(r, head), nil
+		},
+	}}
+}
+
+func (s *Server) commitObject(r *Repo, i int) *object {
+	commit := r.Commits[i]
+
+	author := map[string]resolver{
+		"name":  value(commit.AuthorName),
+		"email": value(commit.AuthorEmail),
+		"date":  timeValue(commit.CommittedDate),
+		"user": func(args) (any, error) {
+			if commit.AuthorLogin == "" {
+				return nil, nil
+			}
+			user, _ := s.user(commit.AuthorLogin)
+			return s.userObject(user), nil
+		},
+	}
+
+	return &object{typeName: "Commit", fields: map[string]resolver{
+		"oid":           value(commit.OID),
+		"committedDate": timeValue(commit.CommittedDate),
+		"authoredDate":  timeValue(commit.CommittedDate),
+		"additions":     value(commit.Additions),
+		"deletions":     value(commit.Deletions),
+		"author":        value(&object{typeName: "GitActor", fields: author}),
+		"committer":     value(&object{typeName: "GitActor", fields: author}),
+		"history": func(a args) (any, error) {
+			since, hasSince := a.time("since")
+			until, hasUntil := a.time("until")
+
+			var items []connectionItem
+			for j := i; j < len(r.Commits); j++ {
+				date := r.Commits[j].CommittedDate
+				if hasSince && date.Before(since) || hasUntil && date.After(until) {
+					continue
+				}
+				items = append(items, connectionItem{node: s.commitObject(r, j)})
+			}
+			return connection("Commit", items, a)
+		},
+	}}
+}
+
+// user returns the user stored with that login on any repo, or a user with only the login.
+func (s *Server) user(login string) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[strings.ToLower(login)]; ok {
+		return user, true
+	}
+	return User{Login: login}, false
+}
+
+func (s *Server) userObject(u User) *object {
+	return &object{typeName: "User", fields: map[string]resolver{
+		"id":        value("U_" + u.Login),
+		"login":     value(u.Login),
+		"name":      value(u.Name),
+		"company":   value(u.Company),
+		"location":  value(u.Location),
+		"createdAt": timeValue(u.CreatedAt),
+		"followers": value(count(u.Followers)),
+		"following": value(count(u.Following)),
+		"repositories": func(a args) (any, error) {
+			return count(u.Repositories), nil
+		},
+		"organizations": func(a args) (any, error) {
+			items := make([]connectionItem, len(u.Organizations))
+			for i, org := range u.Organizations {
+				items[i] = connectionItem{node: &object{typeName: "Organization", fields: map[string]resolver{"login": value(org)}}}
+			}
+			return connection("Organization", items, a)
+		},
+		"starredRepositories": func(a args) (any, error) {
+			items := make([]connectionItem, len(u.StarredRepositories))
+			for i, fullName := range u.StarredRepositories {
+				items[i] = connectionItem{node: &object{typeName: "Repository", fields: map[string]resolver{
+					"nameWithOwner": value(fullName),
+				}}}
+			}
+			if a.orderDescending() {
+				slices.Reverse(items)
+			}
+			return connection("Repository", items, a)
+		},
+	}}
+}
+
+// actorObject returns the author of a comment, issue, pull request or review. Logins ending
+// in [bot] are apps and, like on GitHub, come as a Bot with the suffix dropped from the login.
+func actorObject(login string) *object {
+	if login == "" {
+		return nil
+	}
+	if app, ok := strings.CutSuffix(login, "[bot]"); ok {
+		return &object{typeName: "Bot", fields: map[string]resolver{"login": value(app)}}
+	}
+	return &object{typeName: "User", fields: map[string]resolver{"login": value(login)}}
+}
+
+func labelsConnection(labels []string, a args) (any, error) {
+	items := make([]connectionItem, len(labels))
+	for i, label := range labels {
+		items[i] = connectionItem{node: &object{typeName: "Label", fields: map[string]resolver{"name": value(label)}}}
+	}
+	return connection("Label", items, a)
+}
+
+func milestoneObject(title string) *object {
+	if title == "" {
+		return nil
+	}
+	return &object{typeName: "Milestone", fields: map[string]resolver{"title": value(title)}}
+}
+
+func commentsConnection(comments []Comment, a args) (any, error) {
+	items := make([]connectionItem, len(comments))
+	for i, comment := range comments {
+		items[i] = connectionItem{node: &object{typeName: "IssueComment", fields: map[string]resolver{
+			"author":            value(actorObject(comment.Author)),
+			"authorAssociation": value(comment.AuthorAssociation),
+			"createdAt":         timeValue(comment.CreatedAt),
+			"body":              value(comment.Body),
+		}}}
+	}
+	return connection("IssueComment", items, a)
+}
+
+func (s *Server) issueObject(r *Repo, issue Issue) *object {
+	return &object{typeName: "Issue", fields: map[string]resolver{
+		"number":            value(issue.Number),
+		"title":             value(issue.Title),
+		"url":               value(fmt.Sprintf("%s/%s/issues/%d", s.URL, r.FullName(), issue.Number)),
+		"state":             value(issue.State),
+		"closed":            value(issue.State == "CLOSED"),
+		"createdAt":         timeValue(issue.CreatedAt),
+		"updatedAt":         timeValue(issue.UpdatedAt),
+		"closedAt":          timeValue(issue.ClosedAt),
+		"author":            value(actorObject(issue.Author)),
+		"authorAssociation": value(issue.AuthorAssociation),
+		"milestone":         value(milestoneObject(issue.Milestone)),
+		"labels": func(a args) (any, error) {
+			return labelsConnection(issue.Labels, a)
+		},
+		"comments": func(a args) (any, error) {
+			return commentsConnection(issue.Comments, a)
+		},
+		"timelineItems": func(a args) (any, error) {
+			itemTypes := a.strings("itemTypes")
+			var items []connectionItem
+			if len(itemTypes) == 0 || slices.Contains(itemTypes, "LABELED_EVENT") {
+				for _, event := range issue.LabelEvents {
+					items = append(items, connectionItem{node: &object{typeName: "LabeledEvent", fields: map[string]resolver{
+						"actor":     value(actorObject(event.Actor)),
+						"label":     value(&object{typeName: "Label", fields: map[string]resolver{"name": value(event.Label)}}),
+						"createdAt": timeValue(event.CreatedAt),
+					}}})
+				}
+			}
+			return connection("IssueTimelineItems", items, a)
+		},
+		"repository": value(&object{typeName: "Repository", fields: map[string]resolver{
+			"nameWithOwner": value(r.FullName()),
+		}}),
+	}}
+}
+
+func (s *Server) pullRequestObject(r *Repo, pr PullRequest) *object {
+	return &object{typeName: "PullRequest", fields: map[string]resolver{
+		"number":            value(pr.Number),
+		"title":             value(pr.Title),
+		"url":               value(fmt.Sprintf("%s/%s/pull/%d", s.URL, r.FullName(), pr.Number)),
+		"state":             value(pr.State),
+		"closed":            value(pr.State != "OPEN"),
+		"merged":            value(pr.State == "MERGED"),
+		"createdAt":         timeValue(pr.CreatedAt),
+		"updatedAt":         timeValue(pr.UpdatedAt),
+		"closedAt":          timeValue(pr.ClosedAt),
+		"mergedAt":          timeValue(pr.MergedAt),
+		"author":            value(actorObject(pr.Author)),
+		"authorAssociation": value(pr.AuthorAssociation),
+		"additions":         value(pr.Additions),
+		"deletions":         value(pr.Deletions),
+		"milestone":         value(milestoneObject(pr.Milestone)),
+		"labels": func(a args) (any, error) {
+			return labelsConnection(pr.Labels, a)
+		},
+		"comments": func(a args) (any, error) {
+			return commentsConnection(pr.Comments, a)
+		},
+		"reviews": func(a args) (any, error) {
+			states := a.strings("states")
+			var items []connectionItem
+			for _, review := range pr.Reviews {
+				if len(states) > 0 && !slices.Contains(states, review.State) {
+					continue
+				}
+				items = append(items, connectionItem{node: &object{typeName: "PullRequestReview", fields: map[string]resolver{
+					"author":      value(actorObject(review.Author)),
+					"state":       value(review.State),
+					"submittedAt": timeValue(review.SubmittedAt),
+					"createdAt":   timeValue(review.SubmittedAt),
+				}}})
+			}
+			return connection("PullRequestReview", items, a)
+		},
+		"repository": value(&object{typeName: "Repository", fields: map[string]resolver{
+			"nameWithOwner": value(r.FullName()),
+		}}),
+	}}
+}
+
+func (s *Server) releaseObject(r *Repo, release Release) *object {
+	return &object{typeName: "Release", fields: map[string]resolver{
+		"name":          value(release.Name),
+		"tagName":       value(release.TagName),
+		"url":           value(fmt.Sprintf("%s/%s/releases/tag/%s", s.URL, r.FullName(), release.TagName)),
+		"createdAt":     timeValue(release.CreatedAt),
+		"publishedAt":   timeValue(release.PublishedAt),
+		"isPrerelease":  value(release.IsPrerelease),
+		"isDraft":       value(release.IsDraft),
+		"author":        value(actorObject(release.Author)),
+		"releaseAssets": func(args) (any, error) { return count(release.Assets), nil },
+	}}
+}
+
+func (s *Server) searchItemObject(item SearchItem) *object {
+	return &object{typeName: item.Type, fields: map[string]resolver{
+		"title":     value(item.Title),
+		"url":       value(item.URL),
+		"state":     value(item.State),
+		"body":      value(item.Body),
+		"closed":    value(item.State == "CLOSED" || item.State == "MERGED"),
+		"createdAt": timeValue(item.CreatedAt),
+		"updatedAt": timeValue(item.UpdatedAt),
+		"author":    value(actorObject(item.Author)),
+		"repository": value(&object{typeName: "Repository", fields: map[string]resolver{
+			"nameWithOwner": value(item.Repository),
+		}}),
+	}}
+}
+
+// restTime formats a time like the REST API does, zero times are null.
+func restTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,488 @@
+// Package ghfake serves GitHub GraphQL and REST requests from in-memory fixtures,
+// so that the repostats clients can be exercised without network or token.
+//
+// The GraphQL endpoint understands the queries issued by repostats: repositories with their
+// stargazers, issues, pull requests, forks, releases and commit history, paged in both
+// directions with cursors, plus search and rate limit. The REST endpoints cover the repository,
+// the stargazers pages, the issues search and raw files.
+//
+// Point a client at the server with the Enterprise options:
+//
+//	srv := ghfake.NewServer(repo)
+//	defer srv.Close()
+//	client := repostats.NewClientGQL(http.DefaultClient, repostats.WithEnterpriseServer(srv.URL))
+package ghfake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRateLimit = 5000
+	// stargazersPagesLimit is the last stargazers page served by the REST API.
+	stargazersPagesLimit = 400
+	// searchResultsLimit is the number of search results reachable by paging.
+	searchResultsLimit = 1000
+)
+
+// Server is a fake GitHub API, the layout of its URLs matches GitHub Enterprise Server.
+type Server struct {
+	// URL is the base URL, to be used with repostats.WithEnterpriseServer.
+	URL string
+	// GraphQLURL, RESTURL and RawURL are the endpoints below URL.
+	GraphQLURL string
+	RESTURL    string
+	RawURL     string
+
+	server *httptest.Server
+
+	mu          sync.Mutex
+	repos       map[string]*Repo
+	users       map[string]User
+	searchFunc  func(searchType, query string) SearchResult
+	budget      *budget
+	budgets     map[string]*budget
+	queries     int
+	requests    int
+	notModified int
+}
+
+// budget is a GraphQL points budget.
+type budget struct {
+	limit     int
+	remaining int
+	resetAt   time.Time
+}
+
+// NewServer starts a server serving repos, it has to be closed with Close.
+func NewServer(repos ...*Repo) *Server {
+	s := &Server{
+		repos:   map[string]*Repo{},
+		users:   map[string]User{},
+		budgets: map[string]*budget{},
+		budget: &budget{
+			limit:     defaultRateLimit,
+			remaining: defaultRateLimit,
+			resetAt:   time.Now().Add(time.Hour).Truncate(time.Second),
+		},
+	}
+
+	for _, repo := range repos {
+		s.AddRepo(repo)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/graphql", s.handleGraphQL)
+	mux.HandleFunc("GET /api/v3/repos/{owner}/{name}", s.handleRepo)
+	mux.HandleFunc("GET /api/v3/repos/{owner}/{name}/stargazers", s.handleStargazers)
+	mux.HandleFunc("GET /api/v3/search/issues", s.handleSearchIssues)
+	mux.HandleFunc("POST /api/v3/app/installations/{id}/access_tokens", s.handleInstallationToken)
+	mux.HandleFunc("GET /raw/{owner}/{name}/{branch}/{path...}", s.handleRaw)
+
+	s.server = httptest.NewServer(s.count(s.conditional(mux)))
+	s.URL = s.server.URL
+	s.GraphQLURL = s.URL + "/api/graphql"
+	s.RESTURL = s.URL + "/api/v3"
+	s.RawURL = s.URL + "/raw"
+
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns an HTTP client for the server.
+func (s *Server) Client() *http.Client {
+	return s.server.Client()
+}
+
+// AddRepo adds or replaces a repo, the users starring it become available to the user query.
+func (s *Server) AddRepo(repo *Repo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo.DefaultBranch == "" {
+		repo.DefaultBranch = "main"
+	}
+
+	s.repos[strings.ToLower(repo.FullName())] = repo
+	for _, stargazer := range repo.Stargazers {
+		s.users[strings.ToLower(stargazer.Login)] = stargazer.User
+	}
+}
+
+// AddUser adds or replaces a user.
+func (s *Server) AddUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[strings.ToLower(user.Login)] = user
+}
+
+// SetSearch sets the function answering search queries, by default searches return nothing.
+// searchType is ISSUE, REPOSITORY or DISCUSSION, the REST issues search uses ISSUE.
+func (s *Server) SetSearch(search func(searchType, query string) SearchResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searchFunc = search
+}
+
+// SetRateLimit sets the GraphQL points budget, every query costs one point.
+// Once remaining reaches zero queries fail with a RATE_LIMITED error.
+// The budget is shared by the tokens without their own budget set with SetTokenRateLimit.
+func (s *Server) SetRateLimit(limit, remaining int, resetAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budget = &budget{limit: limit, remaining: remaining, resetAt: resetAt.Truncate(time.Second)}
+}
+
+// SetTokenRateLimit sets the GraphQL points budget of the requests authenticated with token.
+func (s *Server) SetTokenRateLimit(token string, limit, remaining int, resetAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budgets[token] = &budget{limit: limit, remaining: remaining, resetAt: resetAt.Truncate(time.Second)}
+}
+
+// QueryCount returns the number of GraphQL queries received.
+func (s *Server) QueryCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries
+}
+
+// RequestCount returns the number of HTTP requests received, GraphQL and REST.
+func (s *Server) RequestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// NotModifiedCount returns the number of GET requests answered with 304 Not Modified.
+func (s *Server) NotModifiedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notModified
+}
+
+func (s *Server) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// conditional sets an ETag on the successful GET responses, and answers 304 Not Modified
+// when it matches the If-None-Match header of the request.
+func (s *Server) conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+
+		for name, values := range rec.Header() {
+			w.Header()[name] = values
+		}
+
+		if rec.Code == http.StatusOK {
+			sum := sha256.Sum256(rec.Body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", etag)
+
+			if r.Header.Get("If-None-Match") == etag {
+				s.mu.Lock()
+				s.notModified++
+				s.mu.Unlock()
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	})
+}
+
+func (s *Server) repo(fullName string) *Repo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repos[strings.ToLower(fullName)]
+}
+
+func (s *Server) search(searchType, query string) SearchResult {
+	s.mu.Lock()
+	search := s.searchFunc
+	s.mu.Unlock()
+
+	if search == nil {
+		return SearchResult{}
+	}
+	return search(searchType, query)
+}
+
+// requestToken returns the token of the Authorization header.
+func requestToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if _, token, ok := strings.Cut(authorization, " "); ok {
+		return token
+	}
+	return authorization
+}
+
+// rateLimit returns the budget of token.
+func (s *Server) rateLimit(token string) budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.budgets[token]; ok {
+		return *b
+	}
+	return *s.budget
+}
+
+// spend takes a point for a query, it returns false when the budget is exhausted.
+func (s *Server) spend(token string, header http.Header) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries++
+
+	b, ok := s.budgets[token]
+	if !ok {
+		b = s.budget
+	}
+
+	spent := b.remaining > 0
+	if spent {
+		b.remaining--
+	}
+
+	header.Set("X-RateLimit-Limit", strconv.Itoa(b.limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(b.remaining))
+	header.Set("X-RateLimit-Used", strconv.Itoa(b.limit-b.remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(b.resetAt.Unix(), 10))
+	header.Set("X-RateLimit-Resource", "graphql")
+
+	return spent
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"message": "Problems parsing JSON"})
+		return
+	}
+
+	token := requestToken(r)
+
+	if !s.spend(token, w.Header()) {
+		writeJSON(w, http.StatusOK, map[string]any{"errors": []responseError{{
+			Type:    "RATE_LIMITED",
+			Message: fmt.Sprintf("API rate limit exceeded, resets at %s.", s.rateLimit(token).resetAt.UTC().Format(time.RFC3339)),
+		}}})
+		return
+	}
+
+	selections, err := parseQuery(req.Query)
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"errors": []responseError{{
+			Message: "Parse error: " + err.Error(),
+		}}})
+		return
+	}
+
+	e := &executor{variables: req.Variables}
+	response := map[string]any{"data": e.execute(s.queryRoot(token), selections, nil)}
+	if len(e.errors) > 0 {
+		response["errors"] = e.errors
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func notFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]any{
+		"message":           "Not Found",
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
+
+func (s *Server) handleRepo(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(r.PathValue("owner") + "/" + r.PathValue("name"))
+	if repo == nil {
+		notFound(w)
+		return
+	}
+
+	openIssues := 0
+	for _, issue := range repo.Issues {
+		if issue.State == "OPEN" {
+			openIssues++
+		}
+	}
+	for _, pr := range repo.PullRequests {
+		if pr.State == "OPEN" {
+			openIssues++
+		}
+	}
+
+	var language any
+	if repo.PrimaryLanguage != "" {
+		language = repo.PrimaryLanguage
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name":              repo.Name,
+		"full_name":         repo.FullName(),
+		"description":       repo.Description,
+		"created_at":        restTime(repo.CreatedAt),
+		"stargazers_count":  len(repo.Stargazers),
+		"forks_count":       len(repo.Forks),
+		"open_issues_count": openIssues,
+		"size":              repo.DiskUsage,
+		"language":          language,
+		"archived":          repo.IsArchived,
+		"default_branch":    repo.DefaultBranch,
+	})
+}
+
+// pagination returns the page and per_page query parameters with the REST API defaults.
+func pagination(r *http.Request) (int, int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = 30
+	}
+	return page, min(perPage, 100)
+}
+
+// pageBounds returns the bounds of a page within n items.
+func pageBounds(page, perPage, n int) (int, int) {
+	start := min((page-1)*perPage, n)
+	return start, min(start+perPage, n)
+}
+
+func (s *Server) handleStargazers(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(r.PathValue("owner") + "/" + r.PathValue("name"))
+	if repo == nil {
+		notFound(w)
+		return
+	}
+
+	page, perPage := pagination(r)
+	if page > stargazersPagesLimit {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"message": "In order to keep the API fast for everyone, pagination is limited for this resource.",
+		})
+		return
+	}
+
+	withDates := strings.Contains(r.Header.Get("Accept"), "star+json")
+
+	start, end := pageBounds(page, perPage, len(repo.Stargazers))
+	result := make([]any, 0, end-start)
+	for _, stargazer := range repo.Stargazers[start:end] {
+		user := map[string]any{"login": stargazer.Login}
+		if withDates {
+			result = append(result, map[string]any{"starred_at": restTime(stargazer.StarredAt), "user": user})
+		} else {
+			result = append(result, user)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleSearchIssues(w http.ResponseWriter, r *http.Request) {
+	page, perPage := pagination(r)
+	if (page-1)*perPage >= searchResultsLimit {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"message": "Only the first 1000 search results are available",
+		})
+		return
+	}
+
+	result := s.search("ISSUE", r.URL.Query().Get("q"))
+
+	start, end := pageBounds(page, perPage, len(result.Items))
+	items := make([]any, 0, end-start)
+	for _, item := range result.Items[start:end] {
+		restItem := map[string]any{
+			"title":          item.Title,
+			"html_url":       item.URL,
+			"state":          strings.ToLower(item.State),
+			"created_at":     restTime(item.CreatedAt),
+			"updated_at":     restTime(item.UpdatedAt),
+			"body":           item.Body,
+			"user":           map[string]any{"login": item.Author},
+			"repository_url": s.RESTURL + "/repos/" + item.Repository,
+		}
+		if item.Type == "PullRequest" {
+			restItem["pull_request"] = map[string]any{"url": item.URL}
+		}
+		items = append(items, restItem)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"total_count":        result.Count,
+		"incomplete_results": false,
+		"items":              items,
+	})
+}
+
+// handleInstallationToken exchanges an App JWT for an installation token, the JWT signature
+// is not verified.
+func (s *Server) handleInstallationToken(w http.ResponseWriter, r *http.Request) {
+	if strings.Count(requestToken(r), ".") != 2 {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"message": "A JSON web token could not be decoded"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"token":      "ghs_installation_" + r.PathValue("id"),
+		"expires_at": restTime(time.Now().Add(time.Hour)),
+	})
+}
+
+func (s *Server) handleRaw(w http.ResponseWriter, r *http.Request) {
+	repo := s.repo(r.PathValue("owner") + "/" + r.PathValue("name"))
+	if repo == nil || r.PathValue("branch") != repo.DefaultBranch {
+		http.Error(w, "404: Not Found", http.StatusNotFound)
+		return
+	}
+
+	content, ok := repo.Files[r.PathValue("path")]
+	if !ok {
+		http.Error(w, "404: Not Found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, content)
+}
@@ -2,8 +2,8 @@ package repostats
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"math"
 	"net/http"
 	"slices"
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/emanuelef/github-repo-activity-stats/cache"
 	"github.com/emanuelef/github-repo-activity-stats/deps"
 	"github.com/emanuelef/github-repo-activity-stats/stats"
 	"github.com/go-resty/resty/v2"
@@ -48,44 +49,121 @@ func (c *Counter) Value() int {
 type ClientGQL struct {
 	ghClient    *githubv4.Client
 	restyClient *resty.Client
+	// apiClient sends REST API requests authenticated like the GraphQL queries, through the
+	// transport of the http.Client given to NewClientGQL rather than the WithTransport one
+	apiClient *resty.Client
+	opts      *options
+	limiter   *rateLimiter
 }
 
-func NewClientGQL(oauthClient *http.Client) *ClientGQL {
-	ghClient := githubv4.NewClient(oauthClient)
-	restyClient := resty.NewWithClient(
-		&http.Client{
-			Transport: otelhttp.NewTransport(http.DefaultTransport),
-		},
-	)
-	return &ClientGQL{ghClient: ghClient, restyClient: restyClient}
+func NewClientGQL(oauthClient *http.Client, opts ...Option) *ClientGQL {
+	o := newOptions(opts)
+
+	// copy the client so that the caller's one is left untouched
+	httpClient := &http.Client{}
+	if oauthClient != nil {
+		*httpClient = *oauthClient
+	}
+	if o.tokenPool != nil {
+		httpClient.Transport = o.tokenPool.transport(httpClient.Transport)
+	}
+	if o.userAgent != "" {
+		httpClient.Transport = &userAgentTransport{userAgent: o.userAgent, base: httpClient.Transport}
+	}
+	httpClient.Transport = &rateLimitTransport{base: httpClient.Transport}
+	if o.timeout > 0 {
+		httpClient.Timeout = o.timeout
+	}
+
+	ghClient := githubv4.NewEnterpriseClient(o.graphqlURL, httpClient)
+
+	transport := o.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	restyClient := o.newRestyClient(otelhttp.NewTransport(transport))
+
+	apiTransport := http.DefaultTransport
+	if oauthClient != nil && oauthClient.Transport != nil {
+		apiTransport = oauthClient.Transport
+	}
+	apiClient := o.newRestyClient(otelhttp.NewTransport(apiTransport))
+
+	limiter := newRateLimiter(o.rateLimitPolicy)
+	if o.tokenPool != nil {
+		limiter.budget = o.tokenPool.graphQLBudget
+	}
+
+	return &ClientGQL{ghClient: ghClient, restyClient: restyClient, apiClient: apiClient, opts: o, limiter: limiter}
 }
 
 func (c *ClientGQL) query(ctx context.Context, q any, variables map[string]any) error {
 	ctx, span := tracer.Start(ctx, "graphql-query")
 	defer span.End()
-	err := c.ghClient.Query(ctx, q, variables)
-	return err
+
+	if c.opts.cache == nil || cache.Bypassed(ctx) {
+		return c.queryWithRateLimit(ctx, q, variables)
+	}
+
+	key, err := queryCacheKey(q, variables)
+	if err != nil {
+		return c.queryWithRateLimit(ctx, q, variables)
+	}
+
+	if data, ok := c.opts.cache.Get(key); ok {
+		if err := json.Unmarshal(data, q); err == nil {
+			span.SetAttributes(attribute.Bool("cache.hit", true))
+			return nil
+		}
+	}
+
+	err = c.queryWithRateLimit(ctx, q, variables)
+	if err != nil {
+		return err
+	}
+
+	if data, err := json.Marshal(q); err == nil {
+		c.opts.cache.Set(key, data, c.opts.cacheTTL)
+	}
+
+	return nil
+}
+
+// queryCacheKey identifies a GraphQL query by the type of q, which includes the
+// graphql tags the query is built from, and by its variables.
+func queryCacheKey(q any, variables map[string]any) (string, error) {
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return "", err
+	}
+	return cache.Key("graphql", fmt.Sprintf("%T", q), string(vars)), nil
 }
 
 func (c *ClientGQL) GetAllStarsHistory(ctx context.Context, ghRepo string, repoCreationDate time.Time, updateChannel chan<- int) ([]stats.StarsPerDay, error) {
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
 	owner := repoSplit[0]
 	name := repoSplit[1]
 
 	result := []stats.StarsPerDay{}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	repoCreationDate = repoCreationDate.Truncate(24 * time.Hour)
-	diff := currentTime.Sub(repoCreationDate)
-	days := int(diff.Hours()/24 + 1)
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
 
 	for i := 0; i < days; i++ {
-		result = append(result, stats.StarsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i).Truncate(24 * time.Hour))})
+		result = append(result, stats.StarsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i))})
 	}
 
 	variablesStars := map[string]any{
@@ -114,7 +192,7 @@ func (c *ClientGQL) GetAllStarsHistory(ctx context.Context, ghRepo string, repoC
 	for {
 		err := c.query(ctx, &queryStars, variablesStars)
 		if err != nil {
-			break
+			return nil, err
 		}
 
 		res := queryStars.Repository.Stargazers.Edges
@@ -124,8 +202,11 @@ func (c *ClientGQL) GetAllStarsHistory(ctx context.Context, ghRepo string, repoC
 		}
 
 		for _, star := range res {
-			days := star.StarredAt.Sub(repoCreationDate).Hours() / 24
-			result[int(days)].Stars++
+			if star.StarredAt.After(now) {
+				continue
+			}
+			days := c.opts.daysBetween(repoCreationDate, star.StarredAt)
+			result[days].Stars++
 		}
 
 		if !queryStars.Repository.Stargazers.PageInfo.HasNextPage {
@@ -147,10 +228,6 @@ func (c *ClientGQL) GetAllStarsHistory(ctx context.Context, ghRepo string, repoC
 		}
 	}
 
-	if updateChannel != nil {
-		close(updateChannel)
-	}
-
 	return result, nil
 }
 
@@ -164,7 +241,8 @@ func (c *ClientGQL) getStarsHistory(ctx context.Context, owner, name string, tot
 		return result, nil
 	}
 
-	currentTime := time.Now()
+	currentTime := c.opts.clock.Now()
+	starsAfter := 0
 
 	variablesStars := map[string]any{
 		"owner":       githubv4.String(owner),
@@ -190,7 +268,7 @@ func (c *ClientGQL) getStarsHistory(ctx context.Context, owner, name string, tot
 	}
 
 	for i := 1; i < 31; i++ {
-		result.StarsTimeline = append(result.StarsTimeline, stats.StarsPerDay{Day: stats.JSONDay(currentTime.AddDate(0, 0, -(30 - i)).Truncate(24 * time.Hour))})
+		result.StarsTimeline = append(result.StarsTimeline, stats.StarsPerDay{Day: stats.JSONDay(c.opts.dayStart(currentTime).AddDate(0, 0, -(30 - i)))})
 	}
 
 	for {
@@ -204,15 +282,21 @@ func (c *ClientGQL) getStarsHistory(ctx context.Context, owner, name string, tot
 		res := queryStars.Repository.Stargazers.Edges
 		slices.Reverse(res) // order from most recent to least
 
-		if len(res) > 0 && result.LastStarDate.IsZero() {
-			result.LastStarDate = res[0].StarredAt
-		}
-
 		moreThan30daysFlag := false
 
 		for _, star := range res {
 			days := currentTime.Sub(star.StarredAt).Hours() / 24
 
+			// starred after the clock time
+			if days < 0 {
+				starsAfter++
+				continue
+			}
+
+			if result.LastStarDate.IsZero() {
+				result.LastStarDate = star.StarredAt
+			}
+
 			if days > 30 {
 				moreThan30daysFlag = true
 				break
@@ -234,7 +318,10 @@ func (c *ClientGQL) getStarsHistory(ctx context.Context, owner, name string, tot
 				result.AddedLast30d += 1
 			}
 
-			result.StarsTimeline[29-int(days)].Stars += 1
+			// 30 days ago can be before the first day of the timeline
+			if i := 29 - c.opts.daysBetween(star.StarredAt, currentTime); i >= 0 {
+				result.StarsTimeline[i].Stars += 1
+			}
 		}
 
 		if !queryStars.Repository.Stargazers.PageInfo.HasPreviousPage || moreThan30daysFlag {
@@ -244,6 +331,8 @@ func (c *ClientGQL) getStarsHistory(ctx context.Context, owner, name string, tot
 		variablesStars["starsCursor"] = githubv4.NewString(queryStars.Repository.Stargazers.PageInfo.StartCursor)
 	}
 
+	totalStars -= starsAfter
+
 	if totalStars > 0 {
 		result.AddedPerMille30d = 1000 * (float32(result.AddedLast30d) / float32(totalStars))
 	}
@@ -269,7 +358,8 @@ func (c *ClientGQL) getCommitsShortHistory(ctx context.Context, owner, name stri
 		return result, nil
 	}
 
-	currentTime := time.Now()
+	currentTime := c.opts.clock.Now()
+	commitsAfter := 0
 
 	uniqueAuthors := make(map[string]struct{})
 
@@ -311,7 +401,7 @@ func (c *ClientGQL) getCommitsShortHistory(ctx context.Context, owner, name stri
 	}
 
 	for i := 1; i < 31; i++ {
-		result.CommitsTimeline = append(result.CommitsTimeline, stats.CommitsPerDay{Day: stats.JSONDay(currentTime.AddDate(0, 0, -(30 - i)).Truncate(24 * time.Hour))})
+		result.CommitsTimeline = append(result.CommitsTimeline, stats.CommitsPerDay{Day: stats.JSONDay(c.opts.dayStart(currentTime).AddDate(0, 0, -(30 - i)))})
 	}
 
 	for {
@@ -324,15 +414,21 @@ func (c *ClientGQL) getCommitsShortHistory(ctx context.Context, owner, name stri
 
 		res := queryCommits.Repository.DefaultBranchRef.Target.Commit.History.Edges
 
-		if len(res) > 0 && result.LastCommitDate.IsZero() {
-			result.LastCommitDate = res[0].Node.CommittedDate
-		}
-
 		moreThan30daysFlag := false
 
 		for _, star := range res {
 			days := currentTime.Sub(star.Node.CommittedDate).Hours() / 24
 
+			// committed after the clock time
+			if days < 0 {
+				commitsAfter++
+				continue
+			}
+
+			if result.LastCommitDate.IsZero() {
+				result.LastCommitDate = star.Node.CommittedDate
+			}
+
 			if days > 30 {
 				moreThan30daysFlag = true
 				break
@@ -354,7 +450,10 @@ func (c *ClientGQL) getCommitsShortHistory(ctx context.Context, owner, name stri
 				result.AddedLast30d += 1
 			}
 
-			result.CommitsTimeline[29-int(days)].Commits += 1
+			// 30 days ago can be before the first day of the timeline
+			if i := 29 - c.opts.daysBetween(star.Node.CommittedDate, currentTime); i >= 0 {
+				result.CommitsTimeline[i].Commits += 1
+			}
 			uniqueAuthors[star.Node.Author.User.Id] = struct{}{}
 		}
 
@@ -365,6 +464,8 @@ func (c *ClientGQL) getCommitsShortHistory(ctx context.Context, owner, name stri
 		variablesCommits["commitsCursor"] = githubv4.NewString(queryCommits.Repository.DefaultBranchRef.Target.Commit.History.PageInfo.EndCursor)
 	}
 
+	totalCommits -= commitsAfter
+
 	if totalCommits > 0 {
 		result.AddedPerMille30d = 1000 * (float32(result.AddedLast30d) / float32(totalCommits))
 	}
@@ -386,7 +487,7 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
 	defer func() {
@@ -405,17 +506,16 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 
 	totalStars, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllStarsHistoryTwoWays", "error", err)
 		return result, err
 	}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	repoCreationDate = repoCreationDate.Truncate(24 * time.Hour)
-	diff := currentTime.Sub(repoCreationDate)
-	days := int(diff.Hours()/24 + 1)
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
 
 	for i := 0; i < days; i++ {
-		result = append(result, stats.StarsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i).Truncate(24 * time.Hour))})
+		result = append(result, stats.StarsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i))})
 	}
 
 	type starred struct {
@@ -427,8 +527,6 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 
 	eg, ctx := errgroup.WithContext(ctx)
 
-	fmt.Printf("Total Stars: %d %d %d %d \n", totalStars, int(math.Ceil(float64(totalStars/2)/100))+1, int(math.Floor(float64(totalStars/2)/100))-1, int(math.Ceil(float64(totalStars)/100)))
-
 	forwardLimit := int(math.Ceil(float64(totalStars/2)/100)) + 1
 	backwardLimit := int(math.Floor(float64(totalStars/2) / 100))
 
@@ -437,6 +535,9 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 		backwardLimit = int(math.Ceil(float64(totalStars/2)/100)) + 1
 	}
 
+	c.opts.logger.Debug("fetching stars", "repo", ghRepo, "operation", "GetAllStarsHistoryTwoWays",
+		"totalStars", totalStars, "forwardPages", forwardLimit, "backwardPages", backwardLimit)
+
 	eg.Go(func() error {
 		variablesStars := map[string]any{
 			"owner":       githubv4.String(owner),
@@ -459,7 +560,7 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 		for i := 0; i < forwardLimit; i++ {
 			err := c.query(ctx, &queryStars, variablesStars)
 			if err != nil {
-				fmt.Printf("F %d %v\n", i, err)
+				c.opts.logger.Error("forward query failed", "repo", ghRepo, "operation", "GetAllStarsHistoryTwoWays", "page", i, "error", err)
 				return err
 			}
 
@@ -472,10 +573,10 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 			resultMutex.Lock()
 			for _, star := range res {
 				starID := star.Cursor
-				if _, ok := processedStars[starID]; !ok {
+				if _, ok := processedStars[starID]; !ok && !star.StarredAt.After(now) {
 					processedStars[starID] = struct{}{}
-					days := star.StarredAt.Sub(repoCreationDate).Hours() / 24
-					result[int(days)].Stars++
+					days := c.opts.daysBetween(repoCreationDate, star.StarredAt)
+					result[days].Stars++
 				}
 			}
 			resultMutex.Unlock()
@@ -517,15 +618,13 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 		for i := 0; i < backwardLimit; i++ {
 			err := c.query(ctx, &queryStars, variablesStars)
 			if err != nil {
-				fmt.Printf("B %d %v\n", i, err)
 				starsCursor := ""
 				if v, ok := variablesStars["starsCursor"].(*githubv4.String); ok && v != nil {
 					starsCursor = string(*v)
-				} else {
-					log.Println("Warning: starsCursor is nil or not a githubv4.String")
 				}
 
-				fmt.Println(starsCursor)
+				c.opts.logger.Error("backward query failed", "repo", ghRepo, "operation", "GetAllStarsHistoryTwoWays",
+					"page", i, "cursor", starsCursor, "error", err)
 				return err
 			}
 
@@ -538,10 +637,10 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 			resultMutex.Lock()
 			for _, star := range res {
 				starID := star.Cursor
-				if _, ok := processedStars[starID]; !ok {
+				if _, ok := processedStars[starID]; !ok && !star.StarredAt.After(now) {
 					processedStars[starID] = struct{}{}
-					days := star.StarredAt.Sub(repoCreationDate).Hours() / 24
-					result[int(days)].Stars++
+					days := c.opts.daysBetween(repoCreationDate, star.StarredAt)
+					result[days].Stars++
 				}
 			}
 			resultMutex.Unlock()
@@ -563,8 +662,8 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 
 	if err := eg.Wait(); err != nil {
 		// Handle the first error that occurred.
-		log.Printf("An error occurred: %v", err)
-		return result, err
+		c.opts.logger.Error("fetch failed", "repo", ghRepo, "operation", "GetAllStarsHistoryTwoWays", "error", err)
+		return result, partialResult(err)
 	}
 
 	for i, day := range result {
@@ -578,10 +677,202 @@ func (c *ClientGQL) GetAllStarsHistoryTwoWays(ctx context.Context, ghRepo string
 	return result, nil
 }
 
+// GetAllStarsHistoryIncremental extends a previously computed full stars history with the
+// stars added after lastCursor, the stargazer cursor returned by the previous call.
+// When previous is empty or lastCursor is "" the whole history is fetched from the beginning.
+//
+// Returns the merged timeline, up to the clock time, and the cursor to pass on the next call,
+// the one of the last star counted, so that stars after the clock time are left for that call.
+// To resume a history computed otherwise, see GetLastStargazerCursor.
+func (c *ClientGQL) GetAllStarsHistoryIncremental(ctx context.Context, ghRepo string, previous []stats.StarsPerDay, lastCursor string, updateChannel chan<- int) ([]stats.StarsPerDay, string, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, "", invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	owner := repoSplit[0]
+	name := repoSplit[1]
+
+	if len(previous) == 0 || lastCursor == "" {
+		previous = nil
+		lastCursor = ""
+	}
+
+	var startDate time.Time
+
+	if len(previous) > 0 {
+		// the day of the previous timeline, even if computed in another location
+		year, month, day := time.Time(previous[0].Day).Date()
+		startDate = time.Date(year, month, day, 0, 0, 0, 0, c.opts.location)
+	} else {
+		_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
+		if err != nil {
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllStarsHistoryIncremental", "error", err)
+			return nil, "", err
+		}
+		startDate = c.opts.dayStart(repoCreationDate)
+	}
+
+	now := c.opts.clock.Now()
+	days := c.opts.daysBetween(startDate, now) + 1
+
+	result := make([]stats.StarsPerDay, max(days, len(previous)))
+
+	// days are rebuilt in the location of the client, previous ones could have been read in another
+	for i := range result {
+		result[i].Day = stats.JSONDay(startDate.AddDate(0, 0, i))
+		if i < len(previous) {
+			result[i].Stars = previous[i].Stars
+		}
+	}
+
+	variablesStars := map[string]any{
+		"owner":       githubv4.String(owner),
+		"name":        githubv4.String(name),
+		"starsCursor": (*githubv4.String)(nil),
+	}
+
+	if lastCursor != "" {
+		variablesStars["starsCursor"] = githubv4.NewString(githubv4.String(lastCursor))
+	}
+
+	type starred struct {
+		StarredAt time.Time
+		Cursor    string
+	}
+
+	var queryStars struct {
+		Repository struct {
+			Stargazers struct {
+				Edges    []starred
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"stargazers(first: 100, after: $starsCursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	newCursor := lastCursor
+
+	// stars come oldest first, the ones after the clock time are left for the next call
+	afterNow := false
+
+	for i := 1; !afterNow; i++ {
+		err := c.query(ctx, &queryStars, variablesStars)
+		if err != nil {
+			return nil, "", err
+		}
+
+		res := queryStars.Repository.Stargazers.Edges
+
+		if len(res) == 0 {
+			break
+		}
+
+		for _, star := range res {
+			if star.StarredAt.After(now) {
+				afterNow = true
+				break
+			}
+
+			days := c.opts.daysBetween(startDate, star.StarredAt)
+			if days >= 0 && days < len(result) {
+				result[days].Stars++
+			}
+
+			newCursor = star.Cursor
+		}
+
+		if updateChannel != nil {
+			updateChannel <- i
+		}
+
+		if !queryStars.Repository.Stargazers.PageInfo.HasNextPage {
+			break
+		}
+
+		variablesStars["starsCursor"] = githubv4.NewString(queryStars.Repository.Stargazers.PageInfo.EndCursor)
+	}
+
+	for i, day := range result {
+		if i > 0 {
+			result[i].TotalStars = result[i-1].TotalStars + day.Stars
+		} else {
+			result[i].TotalStars = day.Stars
+		}
+	}
+
+	return result, newCursor, nil
+}
+
+// GetLastStargazerCursor returns the cursor of the last star given at or before the clock time,
+// "" when there are none. With the same fixed clock, it lets GetAllStarsHistoryIncremental
+// resume a full history computed by GetAllStarsHistoryTwoWays, which is faster the first time.
+func (c *ClientGQL) GetLastStargazerCursor(ctx context.Context, ghRepo string) (string, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return "", invalidRepoError(ghRepo)
+	}
+
+	variablesStars := map[string]any{
+		"owner":       githubv4.String(repoSplit[0]),
+		"name":        githubv4.String(repoSplit[1]),
+		"starsCursor": (*githubv4.String)(nil),
+	}
+
+	var queryStars struct {
+		Repository struct {
+			Stargazers struct {
+				Edges []struct {
+					StarredAt time.Time
+					Cursor    string
+				}
+				PageInfo struct {
+					StartCursor     githubv4.String
+					HasPreviousPage bool
+				}
+			} `graphql:"stargazers(last: 100, before: $starsCursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	now := c.opts.clock.Now()
+
+	// usually the last page, unless many stars came after the clock time
+	for {
+		err := c.query(ctx, &queryStars, variablesStars)
+		if err != nil {
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetLastStargazerCursor", "error", err)
+			return "", err
+		}
+
+		edges := queryStars.Repository.Stargazers.Edges
+		for i := len(edges) - 1; i >= 0; i-- {
+			if !edges[i].StarredAt.After(now) {
+				return edges[i].Cursor, nil
+			}
+		}
+
+		if len(edges) == 0 || !queryStars.Repository.Stargazers.PageInfo.HasPreviousPage {
+			return "", nil
+		}
+
+		variablesStars["starsCursor"] = githubv4.NewString(queryStars.Repository.Stargazers.PageInfo.StartCursor)
+	}
+}
+
 func (c *ClientGQL) GetRecentStarsHistoryTwoWays(ctx context.Context, ghRepo string, lastDays int, updateChannel chan<- int) ([]stats.StarsPerDay, error) {
 	repoSplit := strings.Split(ghRepo, "/")
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 	defer func() {
 		if updateChannel != nil {
@@ -597,16 +888,16 @@ func (c *ClientGQL) GetRecentStarsHistoryTwoWays(ctx context.Context, ghRepo str
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetRecentStarsHistoryTwoWays", "error", err)
 		return result, err
 	}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	startDate := currentTime.AddDate(0, 0, -lastDays).Truncate(24 * time.Hour)
-	if startDate.Before(repoCreationDate.Truncate(24 * time.Hour)) {
-		startDate = repoCreationDate.Truncate(24 * time.Hour)
+	currentTime := c.opts.dayStart(c.opts.clock.Now())
+	startDate := currentTime.AddDate(0, 0, -lastDays)
+	if startDate.Before(c.opts.dayStart(repoCreationDate)) {
+		startDate = c.opts.dayStart(repoCreationDate)
 	}
-	days := int(currentTime.Sub(startDate).Hours() / 24)
+	days := c.opts.daysBetween(startDate, currentTime)
 	for i := 0; i < days; i++ {
 		result = append(result, stats.StarsPerDay{Day: stats.JSONDay(startDate.AddDate(0, 0, i))})
 	}
@@ -652,7 +943,7 @@ func (c *ClientGQL) GetRecentStarsHistoryTwoWays(ctx context.Context, ghRepo str
 				if _, ok := processedStars[starID]; !ok {
 					processedStars[starID] = struct{}{}
 					if !star.StarredAt.Before(startDate) && star.StarredAt.Before(currentTime) {
-						daysSinceStart := int(star.StarredAt.Sub(startDate).Hours() / 24)
+						daysSinceStart := c.opts.daysBetween(startDate, star.StarredAt)
 						if dayIndex := daysSinceStart; dayIndex >= 0 && dayIndex < len(result) {
 							result[dayIndex].Stars++
 						}
@@ -676,8 +967,8 @@ func (c *ClientGQL) GetRecentStarsHistoryTwoWays(ctx context.Context, ghRepo str
 	})
 
 	if err := eg.Wait(); err != nil {
-		log.Printf("An error occurred: %v", err)
-		return result, err
+		c.opts.logger.Error("fetch failed", "repo", ghRepo, "operation", "GetRecentStarsHistoryTwoWays", "error", err)
+		return result, partialResult(err)
 	}
 
 	// Calculate cumulative totals for the period
@@ -700,7 +991,7 @@ func (c *ClientGQL) GetRecentStarsHistoryTwoWays(ctx context.Context, ghRepo str
 func (c *ClientGQL) GetRecentStarsHistoryByHourRange(ctx context.Context, ghRepo string, startTime, endTime time.Time, updateChannel chan<- int) ([]stats.StarsPerHour, error) {
 	repoSplit := strings.Split(ghRepo, "/")
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 	defer func() {
 		if updateChannel != nil {
@@ -716,13 +1007,13 @@ func (c *ClientGQL) GetRecentStarsHistoryByHourRange(ctx context.Context, ghRepo
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetRecentStarsHistoryByHourRange", "error", err)
 		return result, err
 	}
 
 	// Truncate times to hour boundaries
-	startDate := startTime.UTC().Truncate(time.Hour)
-	currentTime := endTime.UTC().Truncate(time.Hour)
+	startDate := c.opts.hourStart(startTime)
+	currentTime := c.opts.hourStart(endTime)
 
 	// If they're the same hour, add 1 hour to endTime to get the partial current hour
 	if currentTime.Equal(startDate) {
@@ -735,8 +1026,8 @@ func (c *ClientGQL) GetRecentStarsHistoryByHourRange(ctx context.Context, ghRepo
 	}
 
 	// Ensure start date is not before repo creation
-	if startDate.Before(repoCreationDate.Truncate(time.Hour)) {
-		startDate = repoCreationDate.Truncate(time.Hour)
+	if startDate.Before(c.opts.hourStart(repoCreationDate)) {
+		startDate = c.opts.hourStart(repoCreationDate)
 	}
 
 	hours := int(currentTime.Sub(startDate).Hours())
@@ -809,8 +1100,8 @@ func (c *ClientGQL) GetRecentStarsHistoryByHourRange(ctx context.Context, ghRepo
 	})
 
 	if err := eg.Wait(); err != nil {
-		log.Printf("An error occurred: %v", err)
-		return result, err
+		c.opts.logger.Error("fetch failed", "repo", ghRepo, "operation", "GetRecentStarsHistoryByHourRange", "error", err)
+		return result, partialResult(err)
 	}
 
 	// Calculate cumulative totals for the period
@@ -825,7 +1116,7 @@ func (c *ClientGQL) GetRecentStarsHistoryByHourRange(ctx context.Context, ghRepo
 // GetRecentStarsHistoryByHour fetches stars history for the last N days with hourly granularity.
 // This is a convenience wrapper around GetRecentStarsHistoryByHourRange.
 func (c *ClientGQL) GetRecentStarsHistoryByHour(ctx context.Context, ghRepo string, lastDays int, updateChannel chan<- int) ([]stats.StarsPerHour, error) {
-	endTime := time.Now().UTC()
+	endTime := c.opts.clock.Now().UTC()
 	startTime := endTime.AddDate(0, 0, -lastDays)
 	return c.GetRecentStarsHistoryByHourRange(ctx, ghRepo, startTime, endTime, updateChannel)
 }
@@ -833,14 +1124,14 @@ func (c *ClientGQL) GetRecentStarsHistoryByHour(ctx context.Context, ghRepo stri
 // GetRecentStarsHistoryByHourSince fetches stars history from a specific start time to now with hourly granularity.
 // This is useful when you know exactly when your cache was last updated.
 func (c *ClientGQL) GetRecentStarsHistoryByHourSince(ctx context.Context, ghRepo string, since time.Time, updateChannel chan<- int) ([]stats.StarsPerHour, error) {
-	return c.GetRecentStarsHistoryByHourRange(ctx, ghRepo, since, time.Now().UTC(), updateChannel)
+	return c.GetRecentStarsHistoryByHourRange(ctx, ghRepo, since, c.opts.clock.Now().UTC(), updateChannel)
 }
 
 // Deprecated: Use GetRecentStarsHistoryByHourRange for more precise control
 func (c *ClientGQL) getRecentStarsHistoryByHourDeprecated(ctx context.Context, ghRepo string, lastDays int, updateChannel chan<- int) ([]stats.StarsPerHour, error) {
 	repoSplit := strings.Split(ghRepo, "/")
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 	defer func() {
 		if updateChannel != nil {
@@ -856,14 +1147,14 @@ func (c *ClientGQL) getRecentStarsHistoryByHourDeprecated(ctx context.Context, g
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "getRecentStarsHistoryByHourDeprecated", "error", err)
 		return result, err
 	}
 
-	currentTime := time.Now().UTC().Truncate(time.Hour)
-	startDate := currentTime.AddDate(0, 0, -lastDays).Truncate(time.Hour)
-	if startDate.Before(repoCreationDate.Truncate(time.Hour)) {
-		startDate = repoCreationDate.Truncate(time.Hour)
+	currentTime := c.opts.hourStart(c.opts.clock.Now())
+	startDate := c.opts.hourStart(currentTime.AddDate(0, 0, -lastDays))
+	if startDate.Before(c.opts.hourStart(repoCreationDate)) {
+		startDate = c.opts.hourStart(repoCreationDate)
 	}
 	hours := int(currentTime.Sub(startDate).Hours())
 	for i := 0; i <= hours; i++ {
@@ -935,8 +1226,8 @@ func (c *ClientGQL) getRecentStarsHistoryByHourDeprecated(ctx context.Context, g
 	})
 
 	if err := eg.Wait(); err != nil {
-		log.Printf("An error occurred: %v", err)
-		return result, err
+		c.opts.logger.Error("fetch failed", "repo", ghRepo, "operation", "getRecentStarsHistoryByHourDeprecated", "error", err)
+		return result, partialResult(err)
 	}
 
 	// Calculate cumulative totals for the period
@@ -967,9 +1258,10 @@ func (c *ClientGQL) GetCurrentLimits(ctx context.Context) (*RateLimit, error) {
 		}
 	}
 
-	err := c.query(ctx, &query, nil)
+	// not cached, the limits are always fetched
+	err := c.queryWithRateLimit(ctx, &query, nil)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "operation", "GetCurrentLimits", "error", err)
 		return &RateLimit{}, err
 	}
 
@@ -984,7 +1276,7 @@ func (c *ClientGQL) GetCurrentLimits(ctx context.Context) (*RateLimit, error) {
 func (c *ClientGQL) GetAllStats(ctx context.Context, ghRepo string) (*stats.RepoStats, error) {
 	result := stats.RepoStats{}
 
-	currentTime := time.Now()
+	currentTime := c.opts.clock.Now()
 
 	ctx, span := tracer.Start(ctx, "fetch-repo-stats")
 	defer span.End()
@@ -994,7 +1286,7 @@ func (c *ClientGQL) GetAllStats(ctx context.Context, ghRepo string) (*stats.Repo
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
 	variables := map[string]any{
@@ -1060,11 +1352,12 @@ func (c *ClientGQL) GetAllStats(ctx context.Context, ghRepo string) (*stats.Repo
 
 	err := c.query(ctx, &query, variables)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllStats", "error", err)
 		return &result, err
 	}
-	fmt.Println("Desc:", query.Repository.Description)
-	fmt.Println("Total Commit:", query.Repository.DefaultBranchRef.Target.Commit.History.TotalCount)
+	c.opts.logger.Debug("repo stats", "repo", ghRepo, "operation", "GetAllStats",
+		"description", query.Repository.Description,
+		"totalCommits", query.Repository.DefaultBranchRef.Target.Commit.History.TotalCount)
 
 	result.GHPath = ghRepo
 	result.CreatedAt = query.Repository.CreatedAt
@@ -1100,12 +1393,12 @@ func (c *ClientGQL) GetAllStats(ctx context.Context, ghRepo string) (*stats.Repo
 	if days < 30 {
 		result.StarsHistory, err = c.getStarsHistory(ctx, repoSplit[0], repoSplit[1], result.Stars)
 		if err != nil {
-			log.Printf("%v\n", err)
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllStats", "error", err)
 			return &result, err
 		}
 	} else {
 		for i := 1; i < 31; i++ {
-			result.StarsHistory.StarsTimeline = append(result.StarsTimeline, stats.StarsPerDay{Day: stats.JSONDay(currentTime.AddDate(0, 0, -(30 - i)).Truncate(24 * time.Hour))})
+			result.StarsHistory.StarsTimeline = append(result.StarsTimeline, stats.StarsPerDay{Day: stats.JSONDay(c.opts.dayStart(currentTime).AddDate(0, 0, -(30 - i)))})
 		}
 	}
 
@@ -1115,30 +1408,32 @@ func (c *ClientGQL) GetAllStats(ctx context.Context, ghRepo string) (*stats.Repo
 	if days < 30 {
 		result.CommitsHistory, err = c.getCommitsShortHistory(ctx, repoSplit[0], repoSplit[1], result.Commits)
 		if err != nil {
-			log.Printf("%v\n", err)
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllStats", "error", err)
 			return &result, err
 		}
 	} else {
 		for i := 1; i < 31; i++ {
-			result.CommitsHistory.CommitsTimeline = append(result.CommitsTimeline, stats.CommitsPerDay{Day: stats.JSONDay(currentTime.AddDate(0, 0, -(30 - i)).Truncate(24 * time.Hour))})
+			result.CommitsHistory.CommitsTimeline = append(result.CommitsTimeline, stats.CommitsPerDay{Day: stats.JSONDay(c.opts.dayStart(currentTime).AddDate(0, 0, -(30 - i)))})
 		}
 	}
 
-	if depFetcher := deps.CreateFetcher(result.Language); depFetcher != nil {
-		depFetcher.GetDepsList(ctx, c.restyClient, ghRepo, &result)
+	if depFetcher := deps.CreateFetcher(result.Language, deps.WithLogger(c.opts.logger), deps.WithRawURL(c.opts.rawURL)); depFetcher != nil {
+		if err := depFetcher.GetDepsList(ctx, c.restyClient, ghRepo, &result); err != nil {
+			c.opts.logger.Warn("getting dependencies failed", "repo", ghRepo, "operation", "GetAllStats", "error", err)
+		}
 	}
 
-	getLivenessScore(ctx, c.restyClient, ghRepo, &result)
+	getLivenessScore(ctx, c.restyClient, ghRepo, &result, currentTime)
 
 	return &result, nil
 }
 
-func getLivenessScore(ctx context.Context, restyClient *resty.Client, ghRepo string, result *stats.RepoStats) {
+func getLivenessScore(ctx context.Context, restyClient *resty.Client, ghRepo string, result *stats.RepoStats, now time.Time) {
 	score := float32(0.0)
 
 	// calculate days since last commit
 	if !result.LastCommitDate.IsZero() {
-		days := time.Now().Sub(result.LastCommitDate).Hours() / 24
+		days := now.Sub(result.LastCommitDate).Hours() / 24
 
 		switch {
 		case days <= 1:
@@ -1164,7 +1459,7 @@ func getLivenessScore(ctx context.Context, restyClient *resty.Client, ghRepo str
 
 	// calculate days since last star
 	if !result.LastStarDate.IsZero() {
-		days := time.Now().Sub(result.LastStarDate).Hours() / 24
+		days := now.Sub(result.LastStarDate).Hours() / 24
 		switch {
 		case days <= 1:
 			score += 20
@@ -1246,7 +1541,7 @@ func (c *ClientGQL) GetTotalStars(ctx context.Context, ghRepo string) (int, time
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return -1, time.Time{}, fmt.Errorf("Repo should be provided as owner/name")
+		return -1, time.Time{}, invalidRepoError(ghRepo)
 	}
 
 	variables := map[string]any{
@@ -1263,7 +1558,7 @@ func (c *ClientGQL) GetTotalStars(ctx context.Context, ghRepo string) (int, time
 
 	err := c.query(ctx, &query, variables)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetTotalStars", "error", err)
 		return 0, time.Time{}, err
 	}
 
@@ -1274,7 +1569,7 @@ func (c *ClientGQL) GetAllIssuesHistory(ctx context.Context, ghRepo string, upda
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
 	defer func() {
@@ -1291,17 +1586,16 @@ func (c *ClientGQL) GetAllIssuesHistory(ctx context.Context, ghRepo string, upda
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllIssuesHistory", "error", err)
 		return result, err
 	}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	repoCreationDate = repoCreationDate.Truncate(24 * time.Hour)
-	diff := currentTime.Sub(repoCreationDate)
-	days := int(diff.Hours()/24 + 1)
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
 
 	for i := 0; i < days; i++ {
-		result = append(result, stats.IssuesPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i).Truncate(24 * time.Hour))})
+		result = append(result, stats.IssuesPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i))})
 	}
 
 	variablesStars := map[string]any{
@@ -1331,7 +1625,7 @@ func (c *ClientGQL) GetAllIssuesHistory(ctx context.Context, ghRepo string, upda
 	for {
 		err := c.query(ctx, &queryStars, variablesStars)
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllIssuesHistory", "error", err)
 			return nil, err
 		}
 
@@ -1342,24 +1636,7 @@ func (c *ClientGQL) GetAllIssuesHistory(ctx context.Context, ghRepo string, upda
 		}
 
 		for _, issue := range res {
-			daysOpened := issue.CreatedAt.Sub(repoCreationDate).Hours() / 24
-
-			if daysOpened < 0 {
-				continue
-			}
-
-			result[int(daysOpened)].Opened++
-
-			if issue.State == "CLOSED" {
-				if !issue.ClosedAt.IsZero() {
-					daysClosed := issue.ClosedAt.Sub(repoCreationDate).Hours() / 24
-					result[int(daysClosed)].Closed++
-				}
-			}
-
-			if issue.State == "OPEN" {
-				result[int(daysOpened)].CurrentlyOpen++
-			}
+			c.countIssue(result, repoCreationDate, now, issue.State, issue.CreatedAt, issue.ClosedAt)
 		}
 
 		if !queryStars.Repository.Issues.PageInfo.HasNextPage {
@@ -1375,26 +1652,56 @@ func (c *ClientGQL) GetAllIssuesHistory(ctx context.Context, ghRepo string, upda
 		}
 	}
 
-	for i, day := range result {
+	totalIssues(result)
+
+	return result, nil
+}
+
+// countIssue adds an issue to a timeline starting on firstDay, as it was at the clock time now.
+func (c *ClientGQL) countIssue(timeline []stats.IssuesPerDay, firstDay, now time.Time, state string, createdAt, closedAt time.Time) {
+	daysOpened := c.opts.daysBetween(firstDay, createdAt)
+
+	if daysOpened < 0 || createdAt.After(now) {
+		return
+	}
+
+	timeline[daysOpened].Opened++
+
+	// closed after the clock time means still open at that time
+	closed := state == "CLOSED" && !closedAt.After(now)
+
+	if closed {
+		if !closedAt.IsZero() {
+			daysClosed := c.opts.daysBetween(firstDay, closedAt)
+			timeline[daysClosed].Closed++
+		}
+	}
+
+	if !closed {
+		timeline[daysOpened].CurrentlyOpen++
+	}
+}
+
+// totalIssues fills the cumulative totals of a timeline.
+func totalIssues(timeline []stats.IssuesPerDay) {
+	for i, day := range timeline {
 		if i > 0 {
-			result[i].TotalOpened = result[i-1].TotalOpened + day.Opened
-			result[i].TotalClosed = result[i-1].TotalClosed + day.Closed
-			result[i].TotalCurrentlyOpen = result[i-1].TotalCurrentlyOpen + day.CurrentlyOpen
+			timeline[i].TotalOpened = timeline[i-1].TotalOpened + day.Opened
+			timeline[i].TotalClosed = timeline[i-1].TotalClosed + day.Closed
+			timeline[i].TotalCurrentlyOpen = timeline[i-1].TotalCurrentlyOpen + day.CurrentlyOpen
 		} else {
-			result[i].TotalOpened = day.Opened
-			result[i].TotalClosed = day.Closed
-			result[i].TotalCurrentlyOpen = day.CurrentlyOpen
+			timeline[i].TotalOpened = day.Opened
+			timeline[i].TotalClosed = day.Closed
+			timeline[i].TotalCurrentlyOpen = day.CurrentlyOpen
 		}
 	}
-
-	return result, nil
 }
 
 func (c *ClientGQL) GetAllForksHistory(ctx context.Context, ghRepo string, updateChannel chan<- int) ([]stats.ForksPerDay, error) {
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
 	defer func() {
@@ -1411,17 +1718,16 @@ func (c *ClientGQL) GetAllForksHistory(ctx context.Context, ghRepo string, updat
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllForksHistory", "error", err)
 		return result, err
 	}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	repoCreationDate = repoCreationDate.Truncate(24 * time.Hour)
-	diff := currentTime.Sub(repoCreationDate)
-	days := int(diff.Hours()/24 + 1)
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
 
 	for i := 0; i < days; i++ {
-		result = append(result, stats.ForksPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i).Truncate(24 * time.Hour))})
+		result = append(result, stats.ForksPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i))})
 	}
 
 	type starred struct {
@@ -1454,7 +1760,7 @@ func (c *ClientGQL) GetAllForksHistory(ctx context.Context, ghRepo string, updat
 	for {
 		err := c.query(ctx, &queryStars, variablesStars)
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllForksHistory", "error", err)
 			return nil, err
 		}
 
@@ -1465,13 +1771,13 @@ func (c *ClientGQL) GetAllForksHistory(ctx context.Context, ghRepo string, updat
 		}
 
 		for _, fork := range res {
-			daysForkCreated := fork.CreatedAt.Sub(repoCreationDate).Hours() / 24
+			daysForkCreated := c.opts.daysBetween(repoCreationDate, fork.CreatedAt)
 
-			if daysForkCreated < 0 {
+			if daysForkCreated < 0 || fork.CreatedAt.After(now) {
 				continue
 			}
 
-			result[int(daysForkCreated)].Forks++
+			result[daysForkCreated].Forks++
 		}
 
 		if !queryStars.Repository.Forks.PageInfo.HasNextPage {
@@ -1501,7 +1807,7 @@ func (c *ClientGQL) GetAllPRsHistory(ctx context.Context, ghRepo string, updateC
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
 	defer func() {
@@ -1510,31 +1816,20 @@ func (c *ClientGQL) GetAllPRsHistory(ctx context.Context, ghRepo string, updateC
 		}
 	}()
 
-	owner := repoSplit[0]
-	name := repoSplit[1]
-
 	result := []stats.PRsPerDay{}
-	counter := &Counter{}
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllPRsHistory", "error", err)
 		return result, err
 	}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	repoCreationDate = repoCreationDate.Truncate(24 * time.Hour)
-	diff := currentTime.Sub(repoCreationDate)
-	days := int(diff.Hours()/24 + 1)
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
 
 	for i := 0; i < days; i++ {
-		result = append(result, stats.PRsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i).Truncate(24 * time.Hour))})
-	}
-
-	variablesStars := map[string]any{
-		"owner":     githubv4.String(owner),
-		"name":      githubv4.String(name),
-		"prsCursor": (*githubv4.String)(nil),
+		result = append(result, stats.PRsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i))})
 	}
 
 	type prs struct {
@@ -1544,94 +1839,74 @@ func (c *ClientGQL) GetAllPRsHistory(ctx context.Context, ghRepo string, updateC
 		CreatedAt time.Time
 	}
 
-	var queryPRs struct {
-		Repository struct {
-			PullRequests struct {
-				Nodes    []prs
-				PageInfo struct {
-					EndCursor   githubv4.String
-					HasNextPage bool
-				}
-			} `graphql:"pullRequests(first: 100, orderBy: {field: CREATED_AT, direction: ASC}, after: $prsCursor)"`
-		} `graphql:"repository(owner: $owner, name: $name)"`
+	err = paginatePullRequests(ctx, c, ghRepo, "GetAllPRsHistory", &Counter{}, updateChannel, func(pr prs) {
+		c.countPR(result, repoCreationDate, now, pr.State, pr.CreatedAt, pr.MergedAt, pr.ClosedAt)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	for {
-		err := c.query(ctx, &queryPRs, variablesStars)
-		if err != nil {
-			fmt.Printf("%v\n", err)
-			return nil, err
-		}
-
-		res := queryPRs.Repository.PullRequests.Nodes
+	totalPRs(result)
 
-		if len(res) == 0 {
-			break
-		}
-
-		for _, pr := range res {
-			daysOpened := pr.CreatedAt.Sub(repoCreationDate).Hours() / 24
+	return result, nil
+}
 
-			if daysOpened < 0 {
-				continue
-			}
+// countPR adds a pull request to a timeline starting on firstDay, as it was at the clock time now.
+func (c *ClientGQL) countPR(timeline []stats.PRsPerDay, firstDay, now time.Time, state string, createdAt, mergedAt, closedAt time.Time) {
+	daysOpened := c.opts.daysBetween(firstDay, createdAt)
 
-			result[int(daysOpened)].Opened++
+	if daysOpened < 0 || createdAt.After(now) {
+		return
+	}
 
-			if pr.State == "MERGED" {
-				if !pr.ClosedAt.IsZero() {
-					daysClosed := pr.MergedAt.Sub(repoCreationDate).Hours() / 24
-					result[int(daysClosed)].Merged++
-				}
-			}
+	timeline[daysOpened].Opened++
 
-			if pr.State == "CLOSED" {
-				if !pr.ClosedAt.IsZero() {
-					daysClosed := pr.ClosedAt.Sub(repoCreationDate).Hours() / 24
-					result[int(daysClosed)].Closed++
-				}
-			}
+	// merged or closed after the clock time means still open at that time
+	if state != "OPEN" && closedAt.After(now) {
+		state = "OPEN"
+	}
 
-			if pr.State == "OPEN" {
-				result[int(daysOpened)].CurrentlyOpen++
-			}
+	if state == "MERGED" {
+		if !closedAt.IsZero() {
+			daysClosed := c.opts.daysBetween(firstDay, mergedAt)
+			timeline[daysClosed].Merged++
 		}
+	}
 
-		if !queryPRs.Repository.PullRequests.PageInfo.HasNextPage {
-			break
+	if state == "CLOSED" {
+		if !closedAt.IsZero() {
+			daysClosed := c.opts.daysBetween(firstDay, closedAt)
+			timeline[daysClosed].Closed++
 		}
+	}
 
-		variablesStars["prsCursor"] = githubv4.NewString(queryPRs.Repository.PullRequests.PageInfo.EndCursor)
-
-		counter.Increment()
-
-		if updateChannel != nil {
-			updateChannel <- counter.Value()
-		}
+	if state == "OPEN" {
+		timeline[daysOpened].CurrentlyOpen++
 	}
+}
 
-	for i, day := range result {
+// totalPRs fills the cumulative totals of a timeline.
+func totalPRs(timeline []stats.PRsPerDay) {
+	for i, day := range timeline {
 		if i > 0 {
-			result[i].TotalOpened = result[i-1].TotalOpened + day.Opened
-			result[i].TotalMerged = result[i-1].TotalMerged + day.Merged
-			result[i].TotalClosed = result[i-1].TotalClosed + day.Closed
-			result[i].TotalCurrentlyOpen = result[i-1].TotalCurrentlyOpen + day.CurrentlyOpen
+			timeline[i].TotalOpened = timeline[i-1].TotalOpened + day.Opened
+			timeline[i].TotalMerged = timeline[i-1].TotalMerged + day.Merged
+			timeline[i].TotalClosed = timeline[i-1].TotalClosed + day.Closed
+			timeline[i].TotalCurrentlyOpen = timeline[i-1].TotalCurrentlyOpen + day.CurrentlyOpen
 		} else {
-			result[i].TotalOpened = day.Opened
-			result[i].TotalMerged = day.Merged
-			result[i].TotalClosed = day.Closed
-			result[i].TotalCurrentlyOpen = day.CurrentlyOpen
+			timeline[i].TotalOpened = day.Opened
+			timeline[i].TotalMerged = day.Merged
+			timeline[i].TotalClosed = day.Closed
+			timeline[i].TotalCurrentlyOpen = day.CurrentlyOpen
 		}
 	}
-
-	return result, nil
 }
 
 func (c *ClientGQL) GetAllCommitsHistory(ctx context.Context, ghRepo string, updateChannel chan<- int) ([]stats.CommitsPerDay, string, error) {
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, "", fmt.Errorf("Repo should be provided as owner/name")
+		return nil, "", invalidRepoError(ghRepo)
 	}
 
 	defer func() {
@@ -1648,17 +1923,16 @@ func (c *ClientGQL) GetAllCommitsHistory(ctx context.Context, ghRepo string, upd
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllCommitsHistory", "error", err)
 		return result, "", err
 	}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	repoCreationDate = repoCreationDate.Truncate(24 * time.Hour)
-	diff := currentTime.Sub(repoCreationDate)
-	days := int(diff.Hours()/24 + 1)
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
 
 	for i := 0; i < days; i++ {
-		result = append(result, stats.CommitsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i).Truncate(24 * time.Hour))})
+		result = append(result, stats.CommitsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i))})
 	}
 
 	type defaultBranch struct {
@@ -1677,13 +1951,13 @@ func (c *ClientGQL) GetAllCommitsHistory(ctx context.Context, ghRepo string, upd
 	var queryDefaultBranch defaultBranch
 	err = c.query(ctx, &queryDefaultBranch, variablesDefaultBranch)
 	if err != nil {
-		log.Printf("Error getting default branch: %v\n", err)
-		// You can choose to return the error or handle it differently
+		c.opts.logger.Error("getting default branch failed", "repo", ghRepo, "operation", "GetAllCommitsHistory", "error", err)
+		return nil, "", err
 	}
 
 	defaultBranchName := queryDefaultBranch.Repository.DefaultBranchRef.Name
 
-	log.Printf("Default branch: %s\n", defaultBranchName)
+	c.opts.logger.Debug("default branch", "repo", ghRepo, "operation", "GetAllCommitsHistory", "branch", defaultBranchName)
 
 	type commit struct {
 		CommittedDate time.Time
@@ -1717,7 +1991,7 @@ func (c *ClientGQL) GetAllCommitsHistory(ctx context.Context, ghRepo string, upd
 	for {
 		err := c.query(ctx, &queryCommits, variablesCommits)
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetAllCommitsHistory", "error", err)
 			return nil, "", err
 		}
 
@@ -1728,13 +2002,13 @@ func (c *ClientGQL) GetAllCommitsHistory(ctx context.Context, ghRepo string, upd
 		}
 
 		for _, commit := range res {
-			daysCommitMade := commit.CommittedDate.Sub(repoCreationDate).Hours() / 24
+			daysCommitMade := c.opts.daysBetween(repoCreationDate, commit.CommittedDate)
 
-			if daysCommitMade < 0 {
+			if daysCommitMade < 0 || commit.CommittedDate.After(now) {
 				continue
 			}
 
-			result[int(daysCommitMade)].Commits++
+			result[daysCommitMade].Commits++
 		}
 
 		if !queryCommits.Repository.Ref.Target.Commit.History.PageInfo.HasNextPage {
@@ -1760,11 +2034,128 @@ func (c *ClientGQL) GetAllCommitsHistory(ctx context.Context, ghRepo string, upd
 	return result, string(defaultBranchName), nil
 }
 
+// GetCommitsHistory returns the commits per day reachable from ref, a branch, tag or commit SHA,
+// or the default branch when empty. Only the commits from since to until are fetched, a zero
+// since starting from the creation of the repo and a zero until ending at the clock time, so
+// TotalCommits counts the commits from since. It returns ErrRefNotFound when ref doesn't
+// resolve to a commit, or to an annotated tag of a commit.
+func (c *ClientGQL) GetCommitsHistory(ctx context.Context, ghRepo string, ref string, since, until time.Time, updateChannel chan<- int) ([]stats.CommitsPerDay, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	now := c.opts.clock.Now()
+	if until.IsZero() || until.After(now) {
+		until = now
+	}
+
+	if since.IsZero() {
+		_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
+		if err != nil {
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetCommitsHistory", "error", err)
+			return nil, err
+		}
+		since = repoCreationDate
+	}
+
+	if since.After(until) {
+		return nil, fmt.Errorf("until must be after or equal to since")
+	}
+
+	result := []stats.CommitsPerDay{}
+	firstDay := c.opts.dayStart(since)
+	days := c.opts.daysBetween(firstDay, until) + 1
+
+	for i := 0; i < days; i++ {
+		result = append(result, stats.CommitsPerDay{Day: stats.JSONDay(firstDay.AddDate(0, 0, i))})
+	}
+
+	type commit struct {
+		CommittedDate time.Time
+	}
+
+	// an annotated tag points to a Tag object instead of a Commit, other expressions
+	// like main:README.md resolve to a Tree or a Blob
+	type commitsQuery struct {
+		Repository struct {
+			Object *struct {
+				Typename string `graphql:"__typename"`
+				Commit   struct {
+					History connection[commit] `graphql:"history(first: 100, after: $cursor, since: $since, until: $until)"`
+				} `graphql:"... on Commit"`
+				Tag struct {
+					Target struct {
+						Typename string `graphql:"__typename"`
+						Commit   struct {
+							History connection[commit] `graphql:"history(first: 100, after: $cursor, since: $since, until: $until)"`
+						} `graphql:"... on Commit"`
+					}
+				} `graphql:"... on Tag"`
+			} `graphql:"object(expression: $ref)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	variables := map[string]any{
+		"ref":   githubv4.String(ref),
+		"since": githubv4.GitTimestamp{Time: since},
+		"until": githubv4.GitTimestamp{Time: until},
+	}
+
+	page := func(query *commitsQuery) (*connection[commit], error) {
+		object := query.Repository.Object
+		switch {
+		case object == nil:
+			return nil, fmt.Errorf("%w: %s in %s", ErrRefNotFound, ref, ghRepo)
+		case object.Typename == "Commit":
+			return &object.Commit.History, nil
+		case object.Typename == "Tag" && object.Tag.Target.Typename == "Commit":
+			return &object.Tag.Target.Commit.History, nil
+		default:
+			return nil, fmt.Errorf("%w: %s in %s is not a commit", ErrRefNotFound, ref, ghRepo)
+		}
+	}
+
+	err := paginateWith(ctx, c, ghRepo, "GetCommitsHistory", variables, &Counter{}, updateChannel, page, func(commit commit) {
+		daysCommitMade := c.opts.daysBetween(firstDay, commit.CommittedDate)
+
+		if daysCommitMade < 0 || daysCommitMade >= days || commit.CommittedDate.After(until) {
+			return
+		}
+
+		result[daysCommitMade].Commits++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, day := range result {
+		if i > 0 {
+			result[i].TotalCommits = result[i-1].TotalCommits + day.Commits
+		} else {
+			result[i].TotalCommits = day.Commits
+		}
+	}
+
+	return result, nil
+}
+
 func (c *ClientGQL) GetNewContributorsHistory(ctx context.Context, ghRepo string, updateChannel chan<- int) ([]stats.NewContributorsPerDay, error) {
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
 	defer func() {
@@ -1781,17 +2172,16 @@ func (c *ClientGQL) GetNewContributorsHistory(ctx context.Context, ghRepo string
 
 	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
 	if err != nil {
-		log.Printf("%v\n", err)
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetNewContributorsHistory", "error", err)
 		return result, err
 	}
 
-	currentTime := time.Now().UTC().Truncate(24 * time.Hour)
-	repoCreationDate = repoCreationDate.Truncate(24 * time.Hour)
-	diff := currentTime.Sub(repoCreationDate)
-	days := int(diff.Hours()/24 + 1)
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
 
 	for i := 0; i < days; i++ {
-		result = append(result, stats.NewContributorsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i).Truncate(24 * time.Hour))})
+		result = append(result, stats.NewContributorsPerDay{Day: stats.JSONDay(repoCreationDate.AddDate(0, 0, i))})
 	}
 
 	variablesContributors := map[string]any{
@@ -1825,7 +2215,7 @@ func (c *ClientGQL) GetNewContributorsHistory(ctx context.Context, ghRepo string
 	for {
 		err := c.query(ctx, &queryContributors, variablesContributors)
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetNewContributorsHistory", "error", err)
 			return nil, err
 		}
 
@@ -1837,14 +2227,14 @@ func (c *ClientGQL) GetNewContributorsHistory(ctx context.Context, ghRepo string
 
 		for _, pr := range res {
 			if pr.State == "MERGED" {
-				daysMerged := pr.MergedAt.Sub(repoCreationDate).Hours() / 24
-				if daysMerged < 0 {
+				daysMerged := c.opts.daysBetween(repoCreationDate, pr.MergedAt)
+				if daysMerged < 0 || pr.MergedAt.After(now) {
 					continue
 				}
 
 				if _, exists := seenContributors[pr.Author.Login]; !exists {
 					seenContributors[pr.Author.Login] = true
-					result[int(daysMerged)].NewContributors++
+					result[daysMerged].NewContributors++
 				}
 			}
 		}
@@ -1877,7 +2267,7 @@ func (c *ClientGQL) GetAllReleasesFeed(ctx context.Context, ghRepo string) ([]st
 	repoSplit := strings.Split(ghRepo, "/")
 
 	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
-		return nil, fmt.Errorf("Repo should be provided as owner/name")
+		return nil, invalidRepoError(ghRepo)
 	}
 
 	owner := repoSplit[0]
@@ -2008,13 +2398,12 @@ func (c *ClientGQL) GetNewPRsCountHistory(ctx context.Context, startDate, endDat
 	ctx, span := tracer.Start(ctx, "fetch-new-prs-count-history")
 	defer span.End()
 
-	// Normalize dates to UTC midnight
-	startDate = startDate.UTC().Truncate(24 * time.Hour)
-	endDate = endDate.UTC().Truncate(24 * time.Hour)
+	// Normalize dates to midnight in the configured location
+	startDate = c.opts.dayStart(startDate)
+	endDate = c.opts.dayStart(endDate)
 
 	// Calculate number of days
-	diff := endDate.Sub(startDate)
-	days := int(diff.Hours()/24) + 1
+	days := c.opts.daysBetween(startDate, endDate) + 1
 
 	if days <= 0 {
 		return nil, fmt.Errorf("end date must be after or equal to start date")
@@ -2029,7 +2418,7 @@ func (c *ClientGQL) GetNewPRsCountHistory(ctx context.Context, startDate, endDat
 		dateStr := currentDay.Format("2006-01-02")
 
 		// Build the search query for public PRs created on this day
-		query := fmt.Sprintf("created:%s..%s is:public is:pr", dateStr, dateStr)
+		query := fmt.Sprintf("created:%s is:public is:pr", c.opts.searchDayRange(currentDay))
 
 		// Define the GraphQL query structure
 		var searchQuery struct {
@@ -2090,13 +2479,12 @@ func (c *ClientGQL) GetNewReposCountHistory(ctx context.Context, startDate, endD
 	ctx, span := tracer.Start(ctx, "fetch-new-repos-count-history")
 	defer span.End()
 
-	// Normalize dates to UTC midnight
-	startDate = startDate.UTC().Truncate(24 * time.Hour)
-	endDate = endDate.UTC().Truncate(24 * time.Hour)
+	// Normalize dates to midnight in the configured location
+	startDate = c.opts.dayStart(startDate)
+	endDate = c.opts.dayStart(endDate)
 
 	// Calculate number of days
-	diff := endDate.Sub(startDate)
-	days := int(diff.Hours()/24) + 1
+	days := c.opts.daysBetween(startDate, endDate) + 1
 
 	if days <= 0 {
 		return nil, fmt.Errorf("end date must be after or equal to start date")
@@ -2113,7 +2501,7 @@ func (c *ClientGQL) GetNewReposCountHistory(ctx context.Context, startDate, endD
 		// Build the search query
 		// Format: created:YYYY-MM-DD..YYYY-MM-DD is:public
 		// Note: GitHub search excludes forks by default, so we need fork:true to include them
-		query := fmt.Sprintf("created:%s..%s is:public", dateStr, dateStr)
+		query := fmt.Sprintf("created:%s is:public", c.opts.searchDayRange(currentDay))
 		if includeForks {
 			query += " fork:true"
 		}
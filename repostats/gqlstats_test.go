@@ -0,0 +1,445 @@
+package repostats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/cache"
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+// newFakeClient serves repos from a ghfake server to a client whose clock is at now.
+func newFakeClient(t *testing.T, now time.Time, repos ...*ghfake.Repo) (*ClientGQL, *ghfake.Server) {
+	t.Helper()
+
+	srv := ghfake.NewServer(repos...)
+	t.Cleanup(srv.Close)
+
+	return NewClientGQL(srv.Client(), WithEnterpriseServer(srv.URL), WithClock(FixedClock(now))), srv
+}
+
+// stargazersAt returns a stargazer for each time, oldest first as the fixtures expect.
+func stargazersAt(times ...time.Time) []ghfake.Stargazer {
+	stargazers := make([]ghfake.Stargazer, len(times))
+	for i, starredAt := range times {
+		stargazers[i] = ghfake.Stargazer{User: ghfake.User{Login: fmt.Sprintf("user%d", i)}, StarredAt: starredAt}
+	}
+	return stargazers
+}
+
+// dayOf returns the UTC day of t.
+func dayOf(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestGetAllStarsHistoryTwoWays(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	created := now.AddDate(0, 0, -100)
+
+	tests := []struct {
+		name  string
+		stars int
+		// stars after end are given after the clock time
+		end time.Time
+	}{
+		{name: "no stars", stars: 0, end: now},
+		{name: "single page", stars: 80, end: now},
+		{name: "pages overlapping under 300 stars", stars: 250, end: now},
+		{name: "exact pages", stars: 1000, end: now},
+		{name: "pages overlapping", stars: 1234, end: now},
+		{name: "stars after the clock time", stars: 500, end: now.AddDate(0, 0, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stargazers := ghfake.GenerateStargazers(tt.stars, created.Add(time.Hour), tt.end)
+			client, _ := newFakeClient(t, now, &ghfake.Repo{Owner: "octo", Name: "repo", CreatedAt: created, Stargazers: stargazers})
+
+			want := map[time.Time]int{}
+			wantTotal := 0
+			for _, stargazer := range stargazers {
+				if stargazer.StarredAt.After(now) {
+					continue
+				}
+				want[dayOf(stargazer.StarredAt)]++
+				wantTotal++
+			}
+
+			got, err := client.GetAllStarsHistoryTwoWays(context.Background(), "octo/repo", nil)
+			if err != nil {
+				t.Fatalf("GetAllStarsHistoryTwoWays() error = %v", err)
+			}
+
+			if wantDays := 101; len(got) != wantDays {
+				t.Fatalf("got %d days, want %d", len(got), wantDays)
+			}
+
+			// each stargazer is counted once, even if both directions fetched it
+			for _, day := range got {
+				if day.Stars != want[time.Time(day.Day)] {
+					t.Errorf("day %s: got %d stars, want %d", time.Time(day.Day).Format(time.DateOnly), day.Stars, want[time.Time(day.Day)])
+				}
+			}
+
+			if total := got[len(got)-1].TotalStars; total != wantTotal {
+				t.Errorf("TotalStars = %d, want %d", total, wantTotal)
+			}
+		})
+	}
+}
+
+func TestGetAllStarsHistoryIncremental(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// the second page of stargazers goes past the clock time of the first run
+	firstRun := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	secondRun := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	stargazers := ghfake.GenerateStargazers(350, created.Add(time.Hour), secondRun.Add(-time.Hour))
+
+	srv := ghfake.NewServer(&ghfake.Repo{Owner: "octo", Name: "repo", CreatedAt: created, Stargazers: stargazers})
+	t.Cleanup(srv.Close)
+
+	clientAt := func(now time.Time, opts ...Option) *ClientGQL {
+		opts = append([]Option{WithEnterpriseServer(srv.URL), WithClock(FixedClock(now))}, opts...)
+		return NewClientGQL(srv.Client(), opts...)
+	}
+
+	tests := []struct {
+		name string
+		// first returns the history and the cursor at firstRun
+		first func(t *testing.T, client *ClientGQL) ([]stats.StarsPerDay, string)
+	}{
+		{
+			name: "incremental from the beginning",
+			first: func(t *testing.T, client *ClientGQL) ([]stats.StarsPerDay, string) {
+				history, cursor, err := client.GetAllStarsHistoryIncremental(context.Background(), "octo/repo", nil, "", nil)
+				if err != nil {
+					t.Fatalf("GetAllStarsHistoryIncremental() error = %v", err)
+				}
+				return history, cursor
+			},
+		},
+		{
+			name: "two ways with the last stargazer cursor",
+			first: func(t *testing.T, client *ClientGQL) ([]stats.StarsPerDay, string) {
+				history, err := client.GetAllStarsHistoryTwoWays(context.Background(), "octo/repo", nil)
+				if err != nil {
+					t.Fatalf("GetAllStarsHistoryTwoWays() error = %v", err)
+				}
+				cursor, err := client.GetLastStargazerCursor(context.Background(), "octo/repo")
+				if err != nil {
+					t.Fatalf("GetLastStargazerCursor() error = %v", err)
+				}
+				return history, cursor
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, cursor := tt.first(t, clientAt(firstRun))
+
+			wantFirst := 0
+			for _, stargazer := range stargazers {
+				if !stargazer.StarredAt.After(firstRun) {
+					wantFirst++
+				}
+			}
+			if total := previous[len(previous)-1].TotalStars; total != wantFirst {
+				t.Fatalf("first run TotalStars = %d, want %d", total, wantFirst)
+			}
+
+			got, _, err := clientAt(secondRun).GetAllStarsHistoryIncremental(context.Background(), "octo/repo", previous, cursor, nil)
+			if err != nil {
+				t.Fatalf("GetAllStarsHistoryIncremental() error = %v", err)
+			}
+
+			want, err := clientAt(secondRun).GetAllStarsHistoryTwoWays(context.Background(), "octo/repo", nil)
+			if err != nil {
+				t.Fatalf("GetAllStarsHistoryTwoWays() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("resumed history differs from the full one, TotalStars %d, want %d",
+					got[len(got)-1].TotalStars, want[len(want)-1].TotalStars)
+			}
+			if total := got[len(got)-1].TotalStars; total != len(stargazers) {
+				t.Errorf("TotalStars = %d, want %d", total, len(stargazers))
+			}
+		})
+	}
+
+	t.Run("previous read in another location", func(t *testing.T) {
+		previous, cursor, err := clientAt(firstRun).GetAllStarsHistoryIncremental(context.Background(), "octo/repo", nil, "", nil)
+		if err != nil {
+			t.Fatalf("GetAllStarsHistoryIncremental() error = %v", err)
+		}
+
+		rome, err := time.LoadLocation("Europe/Rome")
+		if err != nil {
+			t.Skipf("no time zone database: %v", err)
+		}
+
+		got, _, err := clientAt(secondRun, WithLocation(rome)).GetAllStarsHistoryIncremental(context.Background(), "octo/repo", previous, cursor, nil)
+		if err != nil {
+			t.Fatalf("GetAllStarsHistoryIncremental() error = %v", err)
+		}
+
+		for _, day := range got {
+			if loc := time.Time(day.Day).Location(); loc != rome {
+				t.Fatalf("day %v in %v, want %v", time.Time(day.Day), loc, rome)
+			}
+		}
+		if total := got[len(got)-1].TotalStars; total != len(stargazers) {
+			t.Errorf("TotalStars = %d, want %d", total, len(stargazers))
+		}
+	})
+}
+
+func TestGetStarsHistory(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	day := 24 * time.Hour
+
+	stargazers := stargazersAt(
+		ago(45*day),
+		ago(31*day),
+		ago(29*day), // first day of the timeline
+		ago(15*day),
+		ago(13*day),
+		ago(8*day),
+		ago(6*day+23*time.Hour),
+		ago(3*day),
+		ago(25*time.Hour), // more than a day ago, still yesterday
+		ago(23*time.Hour),
+		ago(time.Hour),
+		now.Add(time.Hour), // after the clock time
+	)
+
+	client, _ := newFakeClient(t, now, &ghfake.Repo{Owner: "octo", Name: "repo", CreatedAt: now.AddDate(-1, 0, 0), Stargazers: stargazers})
+
+	got, err := client.getStarsHistory(context.Background(), "octo", "repo", len(stargazers))
+	if err != nil {
+		t.Fatalf("getStarsHistory() error = %v", err)
+	}
+
+	counts := []struct {
+		name      string
+		got, want int
+	}{
+		{"AddedLast24H", got.AddedLast24H, 2},
+		{"AddedLast7d", got.AddedLast7d, 5},
+		{"AddedLast14d", got.AddedLast14d, 7},
+		{"AddedLast30d", got.AddedLast30d, 9},
+	}
+	for _, count := range counts {
+		if count.got != count.want {
+			t.Errorf("%s = %d, want %d", count.name, count.got, count.want)
+		}
+	}
+
+	if want := ago(time.Hour); !got.LastStarDate.Equal(want) {
+		t.Errorf("LastStarDate = %v, want %v", got.LastStarDate, want)
+	}
+
+	date := func(month time.Month, day int) time.Time { return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC) }
+	wantDays := map[time.Time]int{
+		date(time.May, 3):  1,
+		date(time.May, 17): 1,
+		date(time.May, 19): 1,
+		date(time.May, 24): 1,
+		date(time.May, 25): 1,
+		date(time.May, 29): 1,
+		date(time.May, 31): 2,
+		date(time.June, 1): 1,
+	}
+
+	if len(got.StarsTimeline) != 30 {
+		t.Fatalf("got %d days, want 30", len(got.StarsTimeline))
+	}
+	if first := time.Time(got.StarsTimeline[0].Day); !first.Equal(date(time.May, 3)) {
+		t.Errorf("first day = %v, want 2024-05-03", first)
+	}
+	for _, day := range got.StarsTimeline {
+		if day.Stars != wantDays[time.Time(day.Day)] {
+			t.Errorf("day %s: got %d stars, want %d", time.Time(day.Day).Format(time.DateOnly), day.Stars, wantDays[time.Time(day.Day)])
+		}
+	}
+}
+
+func TestQueryCacheBypass(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	srv := ghfake.NewServer(&ghfake.Repo{Owner: "octo", Name: "repo", CreatedAt: now.AddDate(-1, 0, 0)})
+	t.Cleanup(srv.Close)
+
+	client := NewClientGQL(srv.Client(), WithEnterpriseServer(srv.URL), WithClock(FixedClock(now)), WithCache(cache.NewLRU(100), 0))
+	ctx := context.Background()
+
+	queries := func(t *testing.T, run func() error) int {
+		t.Helper()
+		before := srv.QueryCount()
+		for i := 0; i < 2; i++ {
+			if err := run(); err != nil {
+				t.Fatalf("query error = %v", err)
+			}
+		}
+		return srv.QueryCount() - before
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		want int
+	}{
+		{
+			name: "cached",
+			run:  func() error { _, _, err := client.GetTotalStars(ctx, "octo/repo"); return err },
+			want: 1,
+		},
+		{
+			name: "bypassed",
+			run:  func() error { _, _, err := client.GetTotalStars(cache.Bypass(ctx), "octo/repo"); return err },
+			want: 2,
+		},
+		{
+			name: "rate limits",
+			run:  func() error { _, err := client.GetCurrentLimits(ctx); return err },
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queries(t, tt.run); got != tt.want {
+				t.Errorf("sent %d queries, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheIdentity(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	srv := ghfake.NewServer(&ghfake.Repo{Owner: "octo", Name: "repo", CreatedAt: now.AddDate(-1, 0, 0)})
+	t.Cleanup(srv.Close)
+
+	shared := cache.NewLRU(100)
+
+	tests := []struct {
+		name     string
+		identity string
+		want     int
+	}{
+		{name: "first client", identity: "alice", want: 1},
+		{name: "same identity", identity: "alice", want: 0},
+		{name: "other identity", identity: "bob", want: 1},
+		{name: "no identity", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientGQL(srv.Client(), WithEnterpriseServer(srv.URL), WithClock(FixedClock(now)),
+				WithCache(shared, 0), WithCacheIdentity(tt.identity))
+
+			before := srv.QueryCount()
+			if _, _, err := client.GetTotalStars(context.Background(), "octo/repo"); err != nil {
+				t.Fatalf("GetTotalStars() error = %v", err)
+			}
+			if got := srv.QueryCount() - before; got != tt.want {
+				t.Errorf("sent %d queries, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCommitsHistory(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// a commit every 6 hours from January 2, 4 a day, in more than one page
+	first := created.AddDate(0, 0, 1)
+	commits := ghfake.GenerateCommits(250, first, first.Add(249*6*time.Hour))
+	oid := func(i int) string { return fmt.Sprintf("%040x", i+1) } // of the commit i, oldest first
+
+	client, _ := newFakeClient(t, now, &ghfake.Repo{
+		Owner:         "octo",
+		Name:          "repo",
+		CreatedAt:     created,
+		DefaultBranch: "main",
+		Commits:       commits,
+		Refs:          map[string]string{"release-1.0": oid(199)},
+		Tags:          map[string]string{"v0.1": oid(99)},
+		Files:         map[string]string{"README.md": "# repo"},
+	})
+
+	date := func(month time.Month, day int) time.Time { return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name         string
+		ref          string
+		since, until time.Time
+		firstDay     time.Time
+		days         int
+		total        int
+		lastCommit   time.Time // day of the last commit, with the ones after it empty
+	}{
+		{name: "default branch", firstDay: created, days: 70, total: 250, lastCommit: date(time.March, 4)},
+		{name: "branch", ref: "release-1.0", firstDay: created, days: 70, total: 200, lastCommit: date(time.February, 20)},
+		{name: "annotated tag", ref: "v0.1", firstDay: created, days: 70, total: 100, lastCommit: date(time.January, 26)},
+		{
+			// 2 commits on the first and last days, from 12:00 and to 06:00 included
+			name:       "since and until",
+			since:      date(time.January, 11).Add(12 * time.Hour),
+			until:      date(time.January, 20).Add(6 * time.Hour),
+			firstDay:   date(time.January, 11),
+			days:       10,
+			total:      2 + 8*4 + 2,
+			lastCommit: date(time.January, 20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetCommitsHistory(context.Background(), "octo/repo", tt.ref, tt.since, tt.until, nil)
+			if err != nil {
+				t.Fatalf("GetCommitsHistory() error = %v", err)
+			}
+
+			if len(got) != tt.days {
+				t.Fatalf("got %d days, want %d", len(got), tt.days)
+			}
+			if day := time.Time(got[0].Day); !day.Equal(tt.firstDay) {
+				t.Errorf("first day = %v, want %v", day, tt.firstDay)
+			}
+			if total := got[len(got)-1].TotalCommits; total != tt.total {
+				t.Errorf("TotalCommits = %d, want %d", total, tt.total)
+			}
+
+			for _, day := range got {
+				if time.Time(day.Day).After(tt.lastCommit) && day.Commits > 0 {
+					t.Errorf("day %s: got %d commits after the last one", time.Time(day.Day).Format(time.DateOnly), day.Commits)
+				}
+				if time.Time(day.Day).Equal(tt.lastCommit) && day.Commits == 0 {
+					t.Errorf("day %s: got no commits, want the last one", time.Time(day.Day).Format(time.DateOnly))
+				}
+			}
+		})
+	}
+
+	for _, ref := range []string{"missing", "main:README.md", "main:"} {
+		if _, err := client.GetCommitsHistory(context.Background(), "octo/repo", ref, time.Time{}, time.Time{}, nil); !errors.Is(err, ErrRefNotFound) {
+			t.Errorf("GetCommitsHistory(%q) error = %v, want ErrRefNotFound", ref, err)
+		}
+	}
+
+	if _, err := client.GetCommitsHistory(context.Background(), "octo/repo", "", date(time.February, 2), date(time.February, 1), nil); err == nil {
+		t.Error("GetCommitsHistory() with since after until returned no error")
+	}
+}
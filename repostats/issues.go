@@ -0,0 +1,231 @@
+package repostats
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+type issuesQuery[T any] struct {
+	Repository struct {
+		Issues connection[T] `graphql:"issues(first: 100, orderBy: {field: CREATED_AT, direction: ASC}, after: $cursor)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+// paginateIssues fetches all the issues of a repo, oldest first, decoding each one in a T
+// passed to visit. T selects the fields of the issue, like the nodes of the queries of ClientGQL.
+func paginateIssues[T any](ctx context.Context, c *ClientGQL, ghRepo, operation string, counter *Counter, updateChannel chan<- int, visit func(T)) error {
+	return paginate(ctx, c, ghRepo, operation, counter, updateChannel, func(query *issuesQuery[T]) *connection[T] {
+		return &query.Repository.Issues
+	}, visit)
+}
+
+// isMaintainer reports whether an author association gives write access to the repo.
+func isMaintainer(authorAssociation string) bool {
+	switch authorAssociation {
+	case "OWNER", "MEMBER", "COLLABORATOR":
+		return true
+	}
+	return false
+}
+
+// actor is the author of a comment, issue, pull request or review, or the actor of an event.
+// Apps like dependabot are a Bot, whose login has no [bot] suffix.
+type actor struct {
+	Login    string
+	Typename string `graphql:"__typename"`
+}
+
+// isBot reports whether the actor is an app rather than a user.
+func (a actor) isBot() bool {
+	return a.Typename == "Bot"
+}
+
+// GetIssueResponses returns when each issue of a repo, oldest first, was first answered and closed.
+// The first response is the earliest comment, among the first 20, or label, among the first 20
+// labels, from someone other than the author of the issue, bots excluded. Labels can only be
+// applied by people with triage access, so labelling is a maintainer response.
+// Times are in the location of the client, so that they are grouped in its months.
+func (c *ClientGQL) GetIssueResponses(ctx context.Context, ghRepo string, updateChannel chan<- int) ([]stats.IssueResponse, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	type issue struct {
+		Number    int
+		State     string
+		CreatedAt time.Time
+		ClosedAt  time.Time
+		Author    struct {
+			Login string
+		}
+		Comments struct {
+			Nodes []struct {
+				Author            actor
+				AuthorAssociation string
+				CreatedAt         time.Time
+			}
+		} `graphql:"comments(first: 20)"`
+		TimelineItems struct {
+			Nodes []struct {
+				LabeledEvent struct {
+					Actor     actor
+					CreatedAt time.Time
+				} `graphql:"... on LabeledEvent"`
+			}
+		} `graphql:"timelineItems(first: 20, itemTypes: [LABELED_EVENT])"`
+	}
+
+	now := c.opts.clock.Now()
+	result := []stats.IssueResponse{}
+
+	// answers from the author or bots, or after the clock time, don't count
+	isResponse := func(author string, responder actor, at time.Time) bool {
+		return responder.Login != "" && !strings.EqualFold(responder.Login, author) && !responder.isBot() && !at.After(now)
+	}
+
+	err := paginateIssues(ctx, c, ghRepo, "GetIssueResponses", &Counter{}, updateChannel, func(issue issue) {
+		if issue.CreatedAt.After(now) {
+			return
+		}
+
+		response := stats.IssueResponse{
+			Number:    issue.Number,
+			Author:    issue.Author.Login,
+			CreatedAt: issue.CreatedAt.In(c.opts.location),
+		}
+
+		// closed after the clock time means still open at that time
+		if issue.State == "CLOSED" && !issue.ClosedAt.IsZero() && !issue.ClosedAt.After(now) {
+			response.ClosedAt = issue.ClosedAt.In(c.opts.location)
+		}
+
+		respond := func(responder actor, at time.Time, byMaintainer bool) {
+			if !isResponse(issue.Author.Login, responder, at) {
+				return
+			}
+			if response.FirstResponseAt.IsZero() || at.Before(response.FirstResponseAt) {
+				response.FirstResponseAt = at.In(c.opts.location)
+				response.FirstResponder = responder.Login
+				response.ByMaintainer = byMaintainer
+			}
+		}
+
+		for _, comment := range issue.Comments.Nodes {
+			respond(comment.Author, comment.CreatedAt, isMaintainer(comment.AuthorAssociation))
+		}
+		for _, item := range issue.TimelineItems.Nodes {
+			respond(item.LabeledEvent.Actor, item.LabeledEvent.CreatedAt, true)
+		}
+
+		result = append(result, response)
+	})
+	if err != nil {
+		return result, partialResult(err)
+	}
+
+	return result, nil
+}
+
+// SummarizeIssueResponses groups issue responses, as returned by GetIssueResponses, by the month
+// they were opened in, from the first month to the last one, and measures the time it took to
+// answer and close them. Issues answered or closed in a later month count in the month they were opened.
+func SummarizeIssueResponses(responses []stats.IssueResponse) []stats.IssueResponsivenessMonth {
+	type durations struct {
+		opened                                      int
+		firstResponse, maintainer, community, close []float64
+	}
+
+	result := []stats.IssueResponsivenessMonth{}
+	if len(responses) == 0 {
+		return result
+	}
+
+	months := map[time.Time]*durations{}
+	var first, last time.Time
+
+	for _, response := range responses {
+		month := stats.Month.Start(response.CreatedAt)
+		if first.IsZero() || month.Before(first) {
+			first = month
+		}
+		if month.After(last) {
+			last = month
+		}
+
+		if months[month] == nil {
+			months[month] = &durations{}
+		}
+		d := months[month]
+		d.opened++
+
+		if !response.FirstResponseAt.IsZero() {
+			hours := response.FirstResponseAt.Sub(response.CreatedAt).Hours()
+			d.firstResponse = append(d.firstResponse, hours)
+			if response.ByMaintainer {
+				d.maintainer = append(d.maintainer, hours)
+			} else {
+				d.community = append(d.community, hours)
+			}
+		}
+
+		if !response.ClosedAt.IsZero() {
+			d.close = append(d.close, response.ClosedAt.Sub(response.CreatedAt).Hours())
+		}
+	}
+
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		summary := stats.IssueResponsivenessMonth{Month: stats.JSONDay(month)}
+
+		if d := months[month]; d != nil {
+			summary.Opened = d.opened
+			summary.Unanswered = summary.Opened - len(d.firstResponse)
+			summary.FirstResponse = distribution(d.firstResponse)
+			summary.MaintainerFirstResponse = distribution(d.maintainer)
+			summary.CommunityFirstResponse = distribution(d.community)
+			summary.Close = distribution(d.close)
+		}
+
+		result = append(result, summary)
+	}
+
+	return result
+}
+
+// distribution returns the median and 90th percentile of hours.
+func distribution(hours []float64) stats.DurationDistribution {
+	sorted := slices.Clone(hours)
+	slices.Sort(sorted)
+
+	return stats.DurationDistribution{
+		Count:       len(sorted),
+		MedianHours: percentile(sorted, 0.5),
+		P90Hours:    percentile(sorted, 0.9),
+	}
+}
+
+// percentile returns the p quantile, from 0 to 1, of sorted values, interpolating between the
+// closest ranks, or 0 when there are no values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,75 @@
+package repostats
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+func TestGetIssueResponsesSkipsBots(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	opened := now.AddDate(0, 0, -10)
+
+	repo := &ghfake.Repo{
+		Owner:     "octo",
+		Name:      "repo",
+		CreatedAt: opened.AddDate(-1, 0, 0),
+		Issues: []ghfake.Issue{
+			{
+				Number:    1,
+				State:     "OPEN",
+				CreatedAt: opened,
+				Author:    "alice",
+				Comments: []ghfake.Comment{
+					{Author: "dependabot[bot]", AuthorAssociation: "NONE", CreatedAt: opened.Add(time.Hour)},
+					{Author: "alice", AuthorAssociation: "NONE", CreatedAt: opened.Add(2 * time.Hour)},
+					{Author: "bob", AuthorAssociation: "MEMBER", CreatedAt: opened.Add(5 * time.Hour)},
+				},
+				LabelEvents: []ghfake.LabelEvent{
+					{Actor: "github-actions[bot]", Label: "triage", CreatedAt: opened.Add(30 * time.Minute)},
+				},
+			},
+			{
+				Number:    2,
+				State:     "CLOSED",
+				CreatedAt: opened.Add(time.Hour),
+				ClosedAt:  opened.Add(3 * time.Hour),
+				Author:    "carol",
+				Comments: []ghfake.Comment{
+					{Author: "stale[bot]", AuthorAssociation: "NONE", CreatedAt: opened.Add(2 * time.Hour)},
+				},
+			},
+		},
+	}
+
+	client, _ := newFakeClient(t, now, repo)
+
+	responses, err := client.GetIssueResponses(context.Background(), "octo/repo", nil)
+	if err != nil {
+		t.Fatalf("GetIssueResponses() error = %v", err)
+	}
+
+	want := []stats.IssueResponse{
+		{
+			Number:          1,
+			Author:          "alice",
+			CreatedAt:       opened,
+			FirstResponseAt: opened.Add(5 * time.Hour),
+			FirstResponder:  "bob",
+			ByMaintainer:    true,
+		},
+		{
+			Number:    2,
+			Author:    "carol",
+			CreatedAt: opened.Add(time.Hour),
+			ClosedAt:  opened.Add(3 * time.Hour),
+		},
+	}
+	if !reflect.DeepEqual(responses, want) {
+		t.Errorf("responses = %+v, want %+v", responses, want)
+	}
+}
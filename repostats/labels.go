@@ -0,0 +1,270 @@
+package repostats
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+// UnlabeledGroup is the group of the issues and pull requests without labels.
+const UnlabeledGroup = "unlabeled"
+
+// DefaultLabelGroups groups the normalized names of common labels with the same meaning.
+var DefaultLabelGroups = map[string]string{
+	"defect":            "bug",
+	"regression":        "bug",
+	"crash":             "bug",
+	"bug report":        "bug",
+	"feature":           "enhancement",
+	"feature request":   "enhancement",
+	"new feature":       "enhancement",
+	"improvement":       "enhancement",
+	"docs":              "documentation",
+	"doc":               "documentation",
+	"first timers only": "good first issue",
+	"beginner friendly": "good first issue",
+	"good first bug":    "good first issue",
+	"easy":              "good first issue",
+	"support":           "question",
+	"deps":              "dependencies",
+	"wont fix":          "wontfix",
+	"vulnerability":     "security",
+	"perf":              "performance",
+	"needs triage":      "triage",
+	"awaiting triage":   "triage",
+	"needs more info":   "needs info",
+	"waiting for info":  "needs info",
+	"more info needed":  "needs info",
+}
+
+// LabelBreakdown selects the issues or pull requests of a timeline broken down by label and
+// how their labels are grouped. Empty filters keep all of them.
+type LabelBreakdown struct {
+	// Groups maps normalized label names to the group they are counted in, DefaultLabelGroups
+	// when nil. Labels not in Groups are counted under their normalized name.
+	Groups map[string]string
+	// Labels keeps the items with any of these labels or groups.
+	Labels []string
+	// AuthorAssociations keeps the items whose author has one of these associations with the
+	// repo, like OWNER, MEMBER, CONTRIBUTOR or NONE.
+	AuthorAssociations []string
+	// Milestone keeps the items in the milestone with this title.
+	Milestone string
+}
+
+// NormalizeLabel returns the name of a label in lower case, with separators turned into spaces,
+// emojis dropped and type or kind prefixes removed, so that "Type: Bug", "kind/bug" and
+// "🐛 bug" are all "bug".
+func NormalizeLabel(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == '_' || r == ':' || r == '/' || r == '.':
+			return ' '
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r):
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+
+	fields := strings.Fields(name)
+	if len(fields) > 1 && (fields[0] == "type" || fields[0] == "kind") {
+		fields = fields[1:]
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// groups returns the groups of the labels of an item, or UnlabeledGroup.
+func (b LabelBreakdown) groups(labels []string) []string {
+	groups := b.Groups
+	if groups == nil {
+		groups = DefaultLabelGroups
+	}
+
+	result := []string{}
+	for _, label := range labels {
+		normalized := NormalizeLabel(label)
+		if normalized == "" {
+			continue
+		}
+		if group, ok := groups[normalized]; ok {
+			normalized = group
+		}
+		if !slices.Contains(result, normalized) {
+			result = append(result, normalized)
+		}
+	}
+
+	if len(result) == 0 {
+		result = append(result, UnlabeledGroup)
+	}
+
+	return result
+}
+
+// keep reports whether an item with these groups, author association and milestone passes the filters.
+func (b LabelBreakdown) keep(groups []string, authorAssociation, milestone string) bool {
+	if len(b.AuthorAssociations) > 0 && !slices.ContainsFunc(b.AuthorAssociations, func(association string) bool {
+		return strings.EqualFold(association, authorAssociation)
+	}) {
+		return false
+	}
+
+	if b.Milestone != "" && !strings.EqualFold(b.Milestone, milestone) {
+		return false
+	}
+
+	if len(b.Labels) > 0 {
+		wanted := b.groups(b.Labels)
+		return slices.ContainsFunc(groups, func(group string) bool {
+			return slices.Contains(wanted, group)
+		})
+	}
+
+	return true
+}
+
+// labelledItem are the fields of issues and pull requests needed to break them down by label.
+type labelledItem struct {
+	State             string
+	CreatedAt         time.Time
+	ClosedAt          time.Time
+	AuthorAssociation string
+	Milestone         struct {
+		Title string
+	}
+	Labels struct {
+		Nodes []struct {
+			Name string
+		}
+	} `graphql:"labels(first: 20)"`
+}
+
+func (i labelledItem) labelNames() []string {
+	names := make([]string, 0, len(i.Labels.Nodes))
+	for _, label := range i.Labels.Nodes {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+// GetIssuesHistoryByLabel returns the timeline of GetAllIssuesHistory for each group of labels,
+// looking at the first 20 labels of the issues kept by the filters of breakdown.
+// An issue with labels in several groups is counted in each of them.
+func (c *ClientGQL) GetIssuesHistoryByLabel(ctx context.Context, ghRepo string, breakdown LabelBreakdown, updateChannel chan<- int) (map[string][]stats.IssuesPerDay, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
+	if err != nil {
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetIssuesHistoryByLabel", "error", err)
+		return nil, err
+	}
+
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
+
+	result := map[string][]stats.IssuesPerDay{}
+
+	err = paginateIssues(ctx, c, ghRepo, "GetIssuesHistoryByLabel", &Counter{}, updateChannel, func(issue labelledItem) {
+		groups := breakdown.groups(issue.labelNames())
+		if !breakdown.keep(groups, issue.AuthorAssociation, issue.Milestone.Title) {
+			return
+		}
+
+		for _, group := range groups {
+			if _, ok := result[group]; !ok {
+				timeline := make([]stats.IssuesPerDay, days)
+				for i := range timeline {
+					timeline[i].Day = stats.JSONDay(repoCreationDate.AddDate(0, 0, i))
+				}
+				result[group] = timeline
+			}
+			c.countIssue(result[group], repoCreationDate, now, issue.State, issue.CreatedAt, issue.ClosedAt)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, timeline := range result {
+		totalIssues(timeline)
+	}
+
+	return result, nil
+}
+
+// GetPRsHistoryByLabel returns the timeline of GetAllPRsHistory for each group of labels,
+// looking at the first 20 labels of the pull requests kept by the filters of breakdown.
+// A pull request with labels in several groups is counted in each of them.
+func (c *ClientGQL) GetPRsHistoryByLabel(ctx context.Context, ghRepo string, breakdown LabelBreakdown, updateChannel chan<- int) (map[string][]stats.PRsPerDay, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	_, repoCreationDate, err := c.GetTotalStars(ctx, ghRepo)
+	if err != nil {
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetPRsHistoryByLabel", "error", err)
+		return nil, err
+	}
+
+	now := c.opts.clock.Now()
+	repoCreationDate = c.opts.dayStart(repoCreationDate)
+	days := c.opts.daysBetween(repoCreationDate, now) + 1
+
+	type pr struct {
+		labelledItem
+		MergedAt time.Time
+	}
+
+	result := map[string][]stats.PRsPerDay{}
+
+	err = paginatePullRequests(ctx, c, ghRepo, "GetPRsHistoryByLabel", &Counter{}, updateChannel, func(pr pr) {
+		groups := breakdown.groups(pr.labelNames())
+		if !breakdown.keep(groups, pr.AuthorAssociation, pr.Milestone.Title) {
+			return
+		}
+
+		for _, group := range groups {
+			if _, ok := result[group]; !ok {
+				timeline := make([]stats.PRsPerDay, days)
+				for i := range timeline {
+					timeline[i].Day = stats.JSONDay(repoCreationDate.AddDate(0, 0, i))
+				}
+				result[group] = timeline
+			}
+			c.countPR(result[group], repoCreationDate, now, pr.State, pr.CreatedAt, pr.MergedAt, pr.ClosedAt)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, timeline := range result {
+		totalPRs(timeline)
+	}
+
+	return result, nil
+}
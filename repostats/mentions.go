@@ -2,6 +2,7 @@ package repostats
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -49,6 +50,10 @@ func (c *ClientGQL) GetRepoMentions(ctx context.Context, repo string, limit int)
 // Time format: "2024-01-01" or use time.Time
 // Example: GetRepoMentionsWithTimeRange(ctx, "kubernetes/kubernetes", 50, &startDate, &endDate, true)
 func (c *ClientGQL) GetRepoMentionsWithTimeRange(ctx context.Context, repo string, limit int, startDate, endDate *time.Time, excludeSameOwner bool) (*RepoMentionResult, error) {
+	if len(strings.Split(repo, "/")) != 2 {
+		return nil, invalidRepoError(repo)
+	}
+
 	if limit <= 0 {
 		limit = 100
 	}
@@ -71,10 +76,14 @@ func (c *ClientGQL) GetRepoMentionsWithTimeRange(ctx context.Context, repo strin
 	}
 
 	// Search for Issues
+	// results over the search cap are still returned, together with the cap errors
+	var capErrs []error
+
 	issues, err := c.searchIssues(ctx, repo, limit, dateRange)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrSearchCapReached) {
 		return nil, fmt.Errorf("error searching issues: %w", err)
 	}
+	capErrs = append(capErrs, err)
 	if excludeSameOwner {
 		issues = filterByOwner(issues, owner)
 	}
@@ -83,9 +92,10 @@ func (c *ClientGQL) GetRepoMentionsWithTimeRange(ctx context.Context, repo strin
 
 	// Search for Pull Requests
 	prs, err := c.searchPullRequests(ctx, repo, limit, dateRange)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrSearchCapReached) {
 		return nil, fmt.Errorf("error searching pull requests: %w", err)
 	}
+	capErrs = append(capErrs, err)
 	if excludeSameOwner {
 		prs = filterByOwner(prs, owner)
 	}
@@ -94,9 +104,10 @@ func (c *ClientGQL) GetRepoMentionsWithTimeRange(ctx context.Context, repo strin
 
 	// Search for Discussions
 	discussions, err := c.searchDiscussions(ctx, repo, limit, dateRange)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrSearchCapReached) {
 		return nil, fmt.Errorf("error searching discussions: %w", err)
 	}
+	capErrs = append(capErrs, err)
 	if excludeSameOwner {
 		discussions = filterByOwner(discussions, owner)
 	}
@@ -105,7 +116,7 @@ func (c *ClientGQL) GetRepoMentionsWithTimeRange(ctx context.Context, repo strin
 
 	result.TotalMentions = len(result.Mentions)
 
-	return result, nil
+	return result, errors.Join(capErrs...)
 }
 
 // searchIssues searches for issues mentioning the target repo
@@ -182,6 +193,10 @@ func (c *ClientGQL) searchIssues(ctx context.Context, repo string, limit int, da
 		variables["cursor"] = githubv4.NewString(query.Search.PageInfo.EndCursor)
 	}
 
+	if len(mentions) < limit && len(mentions) >= searchResultsCap && query.Search.IssueCount > len(mentions) {
+		return mentions, &SearchCapError{Total: query.Search.IssueCount, Returned: len(mentions)}
+	}
+
 	return mentions, nil
 }
 
@@ -259,6 +274,10 @@ func (c *ClientGQL) searchPullRequests(ctx context.Context, repo string, limit i
 		variables["cursor"] = githubv4.NewString(query.Search.PageInfo.EndCursor)
 	}
 
+	if len(mentions) < limit && len(mentions) >= searchResultsCap && query.Search.IssueCount > len(mentions) {
+		return mentions, &SearchCapError{Total: query.Search.IssueCount, Returned: len(mentions)}
+	}
+
 	return mentions, nil
 }
 
@@ -340,6 +359,10 @@ func (c *ClientGQL) searchDiscussions(ctx context.Context, repo string, limit in
 		variables["cursor"] = githubv4.NewString(query.Search.PageInfo.EndCursor)
 	}
 
+	if len(mentions) < limit && len(mentions) >= searchResultsCap && query.Search.DiscussionCount > len(mentions) {
+		return mentions, &SearchCapError{Total: query.Search.DiscussionCount, Returned: len(mentions)}
+	}
+
 	return mentions, nil
 }
 
@@ -2,8 +2,8 @@ package repostats
 
 import (
 	"fmt"
-	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -66,6 +66,10 @@ func (c *Client) GetRepoMentionsREST(repo string, limitPerType int) (*RepoMentio
 // Results are sorted by creation date (most recent first)
 // Example: GetRepoMentionsRESTWithTimeRange("kubernetes/kubernetes", 30, &startDate, &endDate)
 func (c *Client) GetRepoMentionsRESTWithTimeRange(repo string, limitPerType int, startDate, endDate *time.Time) (*RepoMentionResultREST, error) {
+	if len(strings.Split(repo, "/")) != 2 {
+		return nil, invalidRepoError(repo)
+	}
+
 	if limitPerType <= 0 {
 		limitPerType = 30
 	}
@@ -122,20 +126,25 @@ func (c *Client) searchGitHubREST(query string, limit int, itemType string) ([]R
 			"sort":     "created",
 			"order":    "desc",
 		}).
-		Get(fmt.Sprintf("%s/search/issues", apiGHUrl))
+		Get(fmt.Sprintf("%s/search/issues", c.opts.restURL))
 	if err != nil {
 		return nil, err
 	}
 
+	if resp.StatusCode() == http.StatusUnprocessableEntity {
+		// GitHub answers 422 when paging past the first 1000 search results
+		return nil, fmt.Errorf("%w: %s", ErrSearchCapReached, resp.String())
+	}
+
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode(), resp.String())
+		return nil, restError(resp, query)
 	}
 
 	mentions := []RepoMentionREST{}
 	for _, item := range searchResp.Items {
 		// Extract repository name from repository_url
 		// Format: https://api.github.com/repos/owner/repo
-		repoName := extractRepoFromURL(item.RepositoryURL)
+		repoName := extractRepoFromURL(item.RepositoryURL, c.opts.restURL)
 
 		createdAt, _ := time.Parse(time.RFC3339, item.CreatedAt)
 		updatedAt, _ := time.Parse(time.RFC3339, item.UpdatedAt)
@@ -160,15 +169,15 @@ func (c *Client) searchGitHubREST(query string, limit int, itemType string) ([]R
 		mentions = append(mentions, mention)
 	}
 
-	log.Printf("Found %d %s mentions", len(mentions), itemType)
+	c.opts.logger.Debug("found mentions", "operation", "searchGitHubREST", "type", itemType, "count", len(mentions))
 	return mentions, nil
 }
 
 // extractRepoFromURL extracts owner/repo from GitHub API URL
 // Example: https://api.github.com/repos/kubernetes/kubernetes -> kubernetes/kubernetes
-func extractRepoFromURL(url string) string {
+func extractRepoFromURL(url, apiURL string) string {
 	// URL format: https://api.github.com/repos/owner/repo
-	const prefix = "https://api.github.com/repos/"
+	prefix := apiURL + "/repos/"
 	if len(url) > len(prefix) {
 		return url[len(prefix):]
 	}
@@ -195,14 +204,16 @@ func (c *Client) GetRepoMentionsSummaryREST(repo string) (map[string]int, error)
 				"q":        searchType.query,
 				"per_page": "1",
 			}).
-			Get(fmt.Sprintf("%s/search/issues", apiGHUrl))
+			Get(fmt.Sprintf("%s/search/issues", c.opts.restURL))
 		if err != nil {
 			return nil, err
 		}
 
-		if resp.StatusCode() == http.StatusOK {
-			summary[searchType.name] = searchResp.TotalCount
+		if !resp.IsSuccess() {
+			return nil, restError(resp, repo)
 		}
+
+		summary[searchType.name] = searchResp.TotalCount
 	}
 
 	summary["total"] = summary["issues"] + summary["pull_requests"]
@@ -0,0 +1,278 @@
+package repostats
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/cache"
+	"github.com/go-resty/resty/v2"
+)
+
+// DefaultCacheTTL is used by WithCache when no positive ttl is provided.
+const DefaultCacheTTL = time.Hour
+
+type options struct {
+	cache           cache.Cache
+	cacheTTL        time.Duration
+	cacheIdentity   string
+	conditional     cache.Cache
+	rateLimitPolicy RateLimitPolicy
+	logger          *slog.Logger
+	graphqlURL      string
+	restURL         string
+	rawURL          string
+	userAgent       string
+	transport       http.RoundTripper
+	timeout         time.Duration
+	clock           Clock
+	location        *time.Location
+	tokenPool       *TokenPool
+}
+
+// Option configures a ClientGQL or a Client.
+type Option func(*options)
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		rateLimitPolicy: DefaultRateLimitPolicy,
+		graphqlURL:      graphqlGHUrl,
+		restURL:         apiGHUrl,
+		rawURL:          rawGHUrl,
+		clock:           systemClock{},
+		location:        time.UTC,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.logger == nil {
+		o.logger = slog.New(slog.DiscardHandler)
+	}
+	if o.clock == nil {
+		o.clock = systemClock{}
+	}
+	if o.location == nil {
+		o.location = time.UTC
+	}
+	if o.cacheIdentity != "" {
+		if o.cache != nil {
+			o.cache = cache.Namespace(o.cache, o.cacheIdentity)
+		}
+		if o.conditional != nil {
+			o.conditional = cache.Namespace(o.conditional, o.cacheIdentity)
+		}
+	}
+	return o
+}
+
+// WithCache stores GraphQL and REST responses in c, each entry expiring after ttl.
+// GraphQL entries are keyed by query and variables, REST entries by URL.
+// The token of the http.Client given to NewClientGQL is not part of the keys, so c must
+// not be shared with clients using other tokens, which would be served private data
+// fetched with this one, unless each of them sets its own WithCacheIdentity.
+// Requests whose context comes from cache.Bypass skip c.
+func WithCache(c cache.Cache, ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl <= 0 {
+			ttl = DefaultCacheTTL
+		}
+		o.cache = c
+		o.cacheTTL = ttl
+	}
+}
+
+// WithCacheIdentity keys the entries stored by WithCache and WithConditionalRequests by
+// identity too, like the login or a name of the token the client authenticates with, so
+// that clients with different tokens can share a cache without seeing each other's data.
+func WithCacheIdentity(identity string) Option {
+	return func(o *options) {
+		o.cacheIdentity = identity
+	}
+}
+
+// WithConditionalRequests stores REST and raw file responses in c with their ETag or
+// Last-Modified validators, and revalidates them on the following requests.
+// Unchanged resources are answered with a 304, which doesn't count against the REST rate limit,
+// and served from c. Unlike WithCache, every request still reaches GitHub.
+func WithConditionalRequests(c cache.Cache) Option {
+	return func(o *options) {
+		o.conditional = c
+	}
+}
+
+// WithRateLimitPolicy replaces DefaultRateLimitPolicy, used to wait and retry rate limited queries.
+func WithRateLimitPolicy(policy RateLimitPolicy) Option {
+	return func(o *options) {
+		o.rateLimitPolicy = policy
+	}
+}
+
+// WithLogger logs progress and failures to logger, by default nothing is logged.
+// Every record carries the repo and the operation it refers to.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithGraphQLURL sends GraphQL queries to url instead of https://api.github.com/graphql.
+func WithGraphQLURL(url string) Option {
+	return func(o *options) {
+		o.graphqlURL = url
+	}
+}
+
+// WithRESTURL sends REST requests to url instead of https://api.github.com.
+func WithRESTURL(url string) Option {
+	return func(o *options) {
+		o.restURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithRawURL fetches repository files from url instead of https://raw.githubusercontent.com.
+func WithRawURL(url string) Option {
+	return func(o *options) {
+		o.rawURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithEnterpriseServer points all the endpoints to a GitHub Enterprise Server instance,
+// e.g. https://github.example.com. Raw files are fetched from baseURL/raw,
+// use WithRawURL when subdomain isolation serves them from a raw. subdomain.
+func WithEnterpriseServer(baseURL string) Option {
+	return func(o *options) {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		o.graphqlURL = baseURL + "/api/graphql"
+		o.restURL = baseURL + "/api/v3"
+		o.rawURL = baseURL + "/raw"
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithTransport sets the transport of the unauthenticated REST and raw file requests made
+// by ClientGQL, and of Client when NewClient is given a nil transport.
+// GraphQL requests, and the REST ones needing the same credentials, like the stargazers
+// samples of GetStarsHistoryEstimated, keep using the http.Client passed to NewClientGQL.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithTimeout limits the duration of every single HTTP request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithClock replaces the system clock used for the time windows, like the stars added in the
+// last 30 days, the daily timelines and the liveness score. A clock set in the past computes
+// those as of that date, events after it are ignored. Totals like the stars count are still the
+// current ones, and rate limit waits and cache expiry keep using the system clock.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithLocation buckets the daily and hourly timelines in loc instead of UTC, like
+// time.LoadLocation("Europe/Rome"). Days follow the calendar of loc, lasting 23 or 25 hours
+// when DST starts or ends, and hours are the ones of its clock.
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// FixedClock returns a Clock always telling t, to compute stats as of t or in tests.
+func FixedClock(t time.Time) Clock {
+	return fixedClock(t)
+}
+
+// WithTokenPool authenticates every request with the credentials of pool, picking for each
+// request the one with the most budget left. The http.Client given to NewClientGQL must not
+// authenticate the requests itself, http.DefaultClient or nil can be used.
+func WithTokenPool(pool *TokenPool) Option {
+	return func(o *options) {
+		o.tokenPool = pool
+	}
+}
+
+// restTransport builds the transport of the resty clients on top of base.
+func (o *options) restTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if o.tokenPool != nil {
+		base = o.tokenPool.transport(base)
+	}
+
+	if o.conditional != nil {
+		base = cache.NewConditionalTransport(o.conditional, base)
+	}
+
+	if o.cache != nil {
+		base = cache.NewTransport(o.cache, o.cacheTTL, base)
+	}
+
+	return base
+}
+
+// newRestyClient creates a resty client applying the user agent and timeout options.
+func (o *options) newRestyClient(transport http.RoundTripper) *resty.Client {
+	restyClient := resty.NewWithClient(&http.Client{Transport: o.restTransport(transport)})
+
+	if o.userAgent != "" {
+		restyClient.SetHeader("User-Agent", o.userAgent)
+	}
+
+	if o.timeout > 0 {
+		restyClient.SetTimeout(o.timeout)
+	}
+
+	return restyClient
+}
+
+// userAgentTransport sets the User-Agent header on the GraphQL requests.
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,345 @@
+package repostats
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/sync/errgroup"
+)
+
+// GetStargazerLogins returns the logins of the stargazers of a repo.
+// When maxStargazers is positive only the latest maxStargazers are fetched, otherwise all of
+// them are, paginating from both ends like GetAllStarsHistoryTwoWays.
+func (c *ClientGQL) GetStargazerLogins(ctx context.Context, ghRepo string, maxStargazers int, updateChannel chan<- int) ([]string, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	owner := repoSplit[0]
+	name := repoSplit[1]
+
+	totalStars, _, err := c.GetTotalStars(ctx, ghRepo)
+	if err != nil {
+		c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetStargazerLogins", "error", err)
+		return nil, err
+	}
+
+	forwardLimit := int(math.Ceil(float64(totalStars) / 200))
+	backwardLimit := forwardLimit
+	if maxStargazers > 0 && maxStargazers < totalStars {
+		forwardLimit = 0
+		backwardLimit = int(math.Ceil(float64(maxStargazers) / 100))
+	}
+
+	type stargazer struct {
+		StarredAt time.Time
+		Node      struct {
+			Login string
+		}
+	}
+
+	now := c.opts.clock.Now()
+	counter := &Counter{}
+
+	var resultMutex sync.Mutex
+	logins := map[string]time.Time{}
+
+	addStargazers := func(stargazers []stargazer) {
+		resultMutex.Lock()
+		defer resultMutex.Unlock()
+		for _, star := range stargazers {
+			if !star.StarredAt.After(now) {
+				logins[star.Node.Login] = star.StarredAt
+			}
+		}
+	}
+
+	notify := func() {
+		counter.Increment()
+		if updateChannel != nil {
+			updateChannel <- counter.Value()
+		}
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		variablesStars := map[string]any{
+			"owner":       githubv4.String(owner),
+			"name":        githubv4.String(name),
+			"starsCursor": (*githubv4.String)(nil),
+		}
+
+		var queryStars struct {
+			Repository struct {
+				Stargazers struct {
+					Edges    []stargazer
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"stargazers(first: 100, after: $starsCursor)"`
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		}
+
+		for i := 0; i < forwardLimit; i++ {
+			err := c.query(ctx, &queryStars, variablesStars)
+			if err != nil {
+				c.opts.logger.Error("forward query failed", "repo", ghRepo, "operation", "GetStargazerLogins", "page", i, "error", err)
+				return err
+			}
+
+			notify()
+			addStargazers(queryStars.Repository.Stargazers.Edges)
+
+			if !queryStars.Repository.Stargazers.PageInfo.HasNextPage {
+				break
+			}
+
+			variablesStars["starsCursor"] = githubv4.NewString(queryStars.Repository.Stargazers.PageInfo.EndCursor)
+		}
+		return nil
+	})
+
+	eg.Go(func() error {
+		variablesStars := map[string]any{
+			"owner":       githubv4.String(owner),
+			"name":        githubv4.String(name),
+			"starsCursor": (*githubv4.String)(nil),
+		}
+
+		var queryStars struct {
+			Repository struct {
+				Stargazers struct {
+					Edges    []stargazer
+					PageInfo struct {
+						StartCursor     githubv4.String
+						HasPreviousPage bool
+					}
+				} `graphql:"stargazers(last: 100, before: $starsCursor)"`
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		}
+
+		for i := 0; i < backwardLimit; i++ {
+			err := c.query(ctx, &queryStars, variablesStars)
+			if err != nil {
+				c.opts.logger.Error("backward query failed", "repo", ghRepo, "operation", "GetStargazerLogins", "page", i, "error", err)
+				return err
+			}
+
+			notify()
+			addStargazers(queryStars.Repository.Stargazers.Edges)
+
+			if !queryStars.Repository.Stargazers.PageInfo.HasPreviousPage {
+				break
+			}
+
+			variablesStars["starsCursor"] = githubv4.NewString(queryStars.Repository.Stargazers.PageInfo.StartCursor)
+		}
+		return nil
+	})
+
+	err = eg.Wait()
+
+	result := make([]string, 0, len(logins))
+	for login := range logins {
+		result = append(result, login)
+	}
+
+	// newest first, so that the latest maxStargazers are kept
+	slices.SortFunc(result, func(a, b string) int {
+		return cmp.Or(logins[b].Compare(logins[a]), strings.Compare(a, b))
+	})
+	if maxStargazers > 0 && len(result) > maxStargazers {
+		result = result[:maxStargazers]
+	}
+
+	if err != nil {
+		c.opts.logger.Error("fetch failed", "repo", ghRepo, "operation", "GetStargazerLogins", "error", err)
+		return result, partialResult(err)
+	}
+
+	return result, nil
+}
+
+// GetStargazerOverlap fetches the stargazers of each repo, up to the latest maxStargazers when
+// positive, and compares them pairwise with CompareStargazers.
+func (c *ClientGQL) GetStargazerOverlap(ctx context.Context, repos []string, maxStargazers int) ([]stats.StargazerOverlap, error) {
+	stargazers := make(map[string][]string, len(repos))
+
+	for _, ghRepo := range repos {
+		logins, err := c.GetStargazerLogins(ctx, ghRepo, maxStargazers, nil)
+		if err != nil {
+			return nil, err
+		}
+		stargazers[ghRepo] = logins
+	}
+
+	return CompareStargazers(stargazers), nil
+}
+
+// CompareStargazers returns the overlap of the stargazers of every pair of repos,
+// sorted by decreasing Jaccard index.
+func CompareStargazers(stargazers map[string][]string) []stats.StargazerOverlap {
+	repos := make([]string, 0, len(stargazers))
+	sets := make(map[string]map[string]struct{}, len(stargazers))
+
+	for repo, logins := range stargazers {
+		repos = append(repos, repo)
+		set := make(map[string]struct{}, len(logins))
+		for _, login := range logins {
+			set[strings.ToLower(login)] = struct{}{}
+		}
+		sets[repo] = set
+	}
+	slices.Sort(repos)
+
+	result := []stats.StargazerOverlap{}
+
+	for i, repoA := range repos {
+		for _, repoB := range repos[i+1:] {
+			setA, setB := sets[repoA], sets[repoB]
+
+			common := 0
+			for login := range setA {
+				if _, ok := setB[login]; ok {
+					common++
+				}
+			}
+
+			overlap := stats.StargazerOverlap{
+				RepoA:       repoA,
+				RepoB:       repoB,
+				StargazersA: len(setA),
+				StargazersB: len(setB),
+				Common:      common,
+			}
+
+			if union := len(setA) + len(setB) - common; union > 0 {
+				overlap.Jaccard = float64(common) / float64(union)
+			}
+			if len(setA) > 0 {
+				overlap.ContainmentA = float64(common) / float64(len(setA))
+			}
+			if len(setB) > 0 {
+				overlap.ContainmentB = float64(common) / float64(len(setB))
+			}
+
+			result = append(result, overlap)
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b stats.StargazerOverlap) int {
+		return cmp.Compare(b.Jaccard, a.Jaccard)
+	})
+
+	return result
+}
+
+// GetRelatedRepos returns the repos most starred by the latest sampleStargazers stargazers
+// of a repo, looking at the 100 repos each of them starred most recently.
+// At most topN repos are returned, or all of them if topN is not positive.
+func (c *ClientGQL) GetRelatedRepos(ctx context.Context, ghRepo string, sampleStargazers, topN int) ([]stats.RelatedRepo, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	variables := map[string]any{
+		"owner":       githubv4.String(repoSplit[0]),
+		"name":        githubv4.String(repoSplit[1]),
+		"starsCursor": (*githubv4.String)(nil),
+	}
+
+	var query struct {
+		Repository struct {
+			Stargazers struct {
+				Edges []struct {
+					StarredAt time.Time
+					Node      struct {
+						StarredRepositories struct {
+							Nodes []struct {
+								NameWithOwner string
+							}
+						} `graphql:"starredRepositories(first: 100, orderBy: {field: STARRED_AT, direction: DESC})"`
+					}
+				}
+				PageInfo struct {
+					StartCursor     githubv4.String
+					HasPreviousPage bool
+				}
+			} `graphql:"stargazers(last: 25, before: $starsCursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	now := c.opts.clock.Now()
+	sampled := 0
+	counts := map[string]*stats.RelatedRepo{}
+
+	var queryErr error
+
+	for sampleStargazers <= 0 || sampled < sampleStargazers {
+		err := c.query(ctx, &query, variables)
+		if err != nil {
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", "GetRelatedRepos", "error", err)
+			queryErr = partialResult(err)
+			break
+		}
+
+		edges := query.Repository.Stargazers.Edges
+		for i := len(edges) - 1; i >= 0 && (sampleStargazers <= 0 || sampled < sampleStargazers); i-- {
+			if edges[i].StarredAt.After(now) {
+				continue
+			}
+
+			sampled++
+			for _, starred := range edges[i].Node.StarredRepositories.Nodes {
+				if strings.EqualFold(starred.NameWithOwner, ghRepo) {
+					continue
+				}
+				key := strings.ToLower(starred.NameWithOwner)
+				if _, ok := counts[key]; !ok {
+					counts[key] = &stats.RelatedRepo{Repo: starred.NameWithOwner}
+				}
+				counts[key].Stargazers++
+			}
+		}
+
+		if len(edges) == 0 || !query.Repository.Stargazers.PageInfo.HasPreviousPage {
+			break
+		}
+
+		variables["starsCursor"] = githubv4.NewString(query.Repository.Stargazers.PageInfo.StartCursor)
+	}
+
+	result := make([]stats.RelatedRepo, 0, len(counts))
+	for _, related := range counts {
+		related.Share = float64(related.Stargazers) / float64(sampled)
+		result = append(result, *related)
+	}
+
+	slices.SortFunc(result, func(a, b stats.RelatedRepo) int {
+		return cmp.Or(b.Stargazers-a.Stargazers, strings.Compare(a.Repo, b.Repo))
+	})
+	if topN > 0 && len(result) > topN {
+		result = result[:topN]
+	}
+
+	return result, queryErr
+}
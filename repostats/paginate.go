@@ -0,0 +1,71 @@
+package repostats
+
+import (
+	"context"
+	"maps"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// connection is a page of the nodes of a GraphQL connection, decoded in T.
+type connection[T any] struct {
+	Nodes    []T
+	PageInfo struct {
+		EndCursor   githubv4.String
+		HasNextPage bool
+	}
+}
+
+// paginate runs the query Q on a repo until the connection returned by page has no more
+// nodes, passing each one to visit. Q pages with the $cursor variable.
+// Each page increments counter, which can be shared by the fetches of the same operation.
+func paginate[Q any, T any](ctx context.Context, c *ClientGQL, ghRepo, operation string, counter *Counter, updateChannel chan<- int, page func(*Q) *connection[T], visit func(T)) error {
+	return paginateWith(ctx, c, ghRepo, operation, nil, counter, updateChannel, func(query *Q) (*connection[T], error) {
+		return page(query), nil
+	}, visit)
+}
+
+// paginateWith is paginate for queries with more variables than $owner, $name and $cursor,
+// and connections that can be missing from a page, in which case page returns an error.
+func paginateWith[Q any, T any](ctx context.Context, c *ClientGQL, ghRepo, operation string, extraVariables map[string]any, counter *Counter, updateChannel chan<- int, page func(*Q) (*connection[T], error), visit func(T)) error {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	variables := map[string]any{
+		"owner":  githubv4.String(repoSplit[0]),
+		"name":   githubv4.String(repoSplit[1]),
+		"cursor": (*githubv4.String)(nil),
+	}
+	maps.Copy(variables, extraVariables)
+
+	var query Q
+
+	for {
+		err := c.query(ctx, &query, variables)
+		if err != nil {
+			c.opts.logger.Error("query failed", "repo", ghRepo, "operation", operation, "error", err)
+			return err
+		}
+
+		nodes, err := page(&query)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes.Nodes {
+			visit(node)
+		}
+
+		counter.Increment()
+
+		if updateChannel != nil {
+			updateChannel <- counter.Value()
+		}
+
+		if len(nodes.Nodes) == 0 || !nodes.PageInfo.HasNextPage {
+			return nil
+		}
+
+		variables["cursor"] = githubv4.NewString(nodes.PageInfo.EndCursor)
+	}
+}
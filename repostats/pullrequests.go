@@ -0,0 +1,215 @@
+package repostats
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+type pullRequestsQuery[T any] struct {
+	Repository struct {
+		PullRequests connection[T] `graphql:"pullRequests(first: 100, orderBy: {field: CREATED_AT, direction: ASC}, after: $cursor)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+// paginatePullRequests fetches all the pull requests of a repo, oldest first, decoding each one
+// in a T passed to visit, like paginateIssues.
+func paginatePullRequests[T any](ctx context.Context, c *ClientGQL, ghRepo, operation string, counter *Counter, updateChannel chan<- int, visit func(T)) error {
+	return paginate(ctx, c, ghRepo, operation, counter, updateChannel, func(query *pullRequestsQuery[T]) *connection[T] {
+		return &query.Repository.PullRequests
+	}, visit)
+}
+
+// GetPullRequestLatencies returns when each pull request of a repo, oldest first, was first
+// reviewed, approved and merged, looking at its first 50 reviews. Reviews from the author
+// of the pull request and from bots don't count.
+// Times are in the location of the client, so that they are grouped in its months.
+func (c *ClientGQL) GetPullRequestLatencies(ctx context.Context, ghRepo string, updateChannel chan<- int) ([]stats.PullRequestLatency, error) {
+	repoSplit := strings.Split(ghRepo, "/")
+
+	if len(repoSplit) != 2 || !strings.Contains(ghRepo, "/") {
+		return nil, invalidRepoError(ghRepo)
+	}
+
+	defer func() {
+		if updateChannel != nil {
+			close(updateChannel)
+		}
+	}()
+
+	type review struct {
+		Author      actor
+		State       string
+		SubmittedAt time.Time
+	}
+
+	type pr struct {
+		Number    int
+		State     string
+		Additions int
+		Deletions int
+		CreatedAt time.Time
+		MergedAt  time.Time
+		ClosedAt  time.Time
+		Author    struct {
+			Login string
+		}
+		Reviews struct {
+			Nodes []review
+		} `graphql:"reviews(first: 50)"`
+	}
+
+	now := c.opts.clock.Now()
+	result := []stats.PullRequestLatency{}
+
+	err := paginatePullRequests(ctx, c, ghRepo, "GetPullRequestLatencies", &Counter{}, updateChannel, func(pr pr) {
+		if pr.CreatedAt.After(now) {
+			return
+		}
+
+		latency := stats.PullRequestLatency{
+			Number:    pr.Number,
+			Author:    pr.Author.Login,
+			State:     pr.State,
+			Additions: pr.Additions,
+			Deletions: pr.Deletions,
+			CreatedAt: pr.CreatedAt.In(c.opts.location),
+			Reviewers: []string{},
+		}
+
+		// merged or closed after the clock time means still open at that time
+		if latency.State != "OPEN" && (pr.ClosedAt.IsZero() || pr.ClosedAt.After(now)) {
+			latency.State = "OPEN"
+		}
+		if latency.State != "OPEN" {
+			latency.ClosedAt = pr.ClosedAt.In(c.opts.location)
+		}
+		if latency.State == "MERGED" && !pr.MergedAt.IsZero() {
+			latency.MergedAt = pr.MergedAt.In(c.opts.location)
+		}
+
+		reviews := pr.Reviews.Nodes
+		slices.SortStableFunc(reviews, func(a, b review) int {
+			return a.SubmittedAt.Compare(b.SubmittedAt)
+		})
+
+		// a round ends when changes are requested, the reviews after the last one are a round too
+		pendingRound := false
+
+		for _, review := range reviews {
+			reviewer := review.Author.Login
+
+			// pending reviews are not submitted yet
+			if review.SubmittedAt.IsZero() || review.SubmittedAt.After(now) {
+				continue
+			}
+			if reviewer == "" || strings.EqualFold(reviewer, pr.Author.Login) || review.Author.isBot() {
+				continue
+			}
+
+			if latency.FirstReviewAt.IsZero() {
+				latency.FirstReviewAt = review.SubmittedAt.In(c.opts.location)
+			}
+			if review.State == "APPROVED" && latency.ApprovedAt.IsZero() {
+				latency.ApprovedAt = review.SubmittedAt.In(c.opts.location)
+			}
+			if !slices.Contains(latency.Reviewers, reviewer) {
+				latency.Reviewers = append(latency.Reviewers, reviewer)
+			}
+
+			pendingRound = true
+			if review.State == "CHANGES_REQUESTED" {
+				latency.ReviewRounds++
+				pendingRound = false
+			}
+		}
+
+		if pendingRound {
+			latency.ReviewRounds++
+		}
+
+		result = append(result, latency)
+	})
+	if err != nil {
+		return result, partialResult(err)
+	}
+
+	return result, nil
+}
+
+// SummarizePullRequestLatencies groups pull request latencies, as returned by
+// GetPullRequestLatencies, by the month the pull requests were opened in, from the first
+// month to the last one. Each stage is measured on the pull requests that reached it.
+func SummarizePullRequestLatencies(latencies []stats.PullRequestLatency) []stats.PRLatencyMonth {
+	type durations struct {
+		opened, merged                                        int
+		firstReview, approval, approvalToMerge, merge, rounds []float64
+		changedLines                                          []float64
+	}
+
+	result := []stats.PRLatencyMonth{}
+	if len(latencies) == 0 {
+		return result
+	}
+
+	months := map[time.Time]*durations{}
+	var first, last time.Time
+
+	for _, latency := range latencies {
+		month := stats.Month.Start(latency.CreatedAt)
+		if first.IsZero() || month.Before(first) {
+			first = month
+		}
+		if month.After(last) {
+			last = month
+		}
+
+		if months[month] == nil {
+			months[month] = &durations{}
+		}
+		d := months[month]
+
+		d.opened++
+		d.changedLines = append(d.changedLines, float64(latency.Additions+latency.Deletions))
+
+		if !latency.FirstReviewAt.IsZero() {
+			d.firstReview = append(d.firstReview, latency.FirstReviewAt.Sub(latency.CreatedAt).Hours())
+			d.rounds = append(d.rounds, float64(latency.ReviewRounds))
+		}
+		if !latency.ApprovedAt.IsZero() {
+			d.approval = append(d.approval, latency.ApprovedAt.Sub(latency.CreatedAt).Hours())
+		}
+		if !latency.MergedAt.IsZero() {
+			d.merged++
+			d.merge = append(d.merge, latency.MergedAt.Sub(latency.CreatedAt).Hours())
+			if !latency.ApprovedAt.IsZero() {
+				d.approvalToMerge = append(d.approvalToMerge, latency.MergedAt.Sub(latency.ApprovedAt).Hours())
+			}
+		}
+	}
+
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		summary := stats.PRLatencyMonth{Month: stats.JSONDay(month)}
+
+		if d := months[month]; d != nil {
+			summary.Opened = d.opened
+			summary.Merged = d.merged
+			summary.TimeToFirstReview = distribution(d.firstReview)
+			summary.TimeToApproval = distribution(d.approval)
+			summary.ApprovalToMerge = distribution(d.approvalToMerge)
+			summary.TimeToMerge = distribution(d.merge)
+
+			slices.Sort(d.rounds)
+			summary.MedianReviewRounds = percentile(d.rounds, 0.5)
+			slices.Sort(d.changedLines)
+			summary.MedianChangedLines = percentile(d.changedLines, 0.5)
+		}
+
+		result = append(result, summary)
+	}
+
+	return result
+}
@@ -0,0 +1,67 @@
+package repostats
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+	"github.com/emanuelef/github-repo-activity-stats/stats"
+)
+
+func TestGetPullRequestLatenciesSkipsBots(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	opened := now.AddDate(0, 0, -10)
+
+	repo := &ghfake.Repo{
+		Owner:     "octo",
+		Name:      "repo",
+		CreatedAt: opened.AddDate(-1, 0, 0),
+		PullRequests: []ghfake.PullRequest{
+			{
+				Number:    1,
+				State:     "MERGED",
+				CreatedAt: opened,
+				MergedAt:  opened.Add(8 * time.Hour),
+				ClosedAt:  opened.Add(8 * time.Hour),
+				Author:    "alice",
+				Additions: 10,
+				Deletions: 2,
+				Reviews: []ghfake.Review{
+					{Author: "renovate[bot]", State: "APPROVED", SubmittedAt: opened.Add(time.Hour)},
+					{Author: "alice", State: "COMMENTED", SubmittedAt: opened.Add(2 * time.Hour)},
+					{Author: "bob", State: "CHANGES_REQUESTED", SubmittedAt: opened.Add(4 * time.Hour)},
+					{Author: "bob", State: "APPROVED", SubmittedAt: opened.Add(6 * time.Hour)},
+				},
+			},
+		},
+	}
+
+	client, _ := newFakeClient(t, now, repo)
+
+	latencies, err := client.GetPullRequestLatencies(context.Background(), "octo/repo", nil)
+	if err != nil {
+		t.Fatalf("GetPullRequestLatencies() error = %v", err)
+	}
+
+	want := []stats.PullRequestLatency{
+		{
+			Number:        1,
+			Author:        "alice",
+			State:         "MERGED",
+			Additions:     10,
+			Deletions:     2,
+			CreatedAt:     opened,
+			FirstReviewAt: opened.Add(4 * time.Hour),
+			ApprovedAt:    opened.Add(6 * time.Hour),
+			MergedAt:      opened.Add(8 * time.Hour),
+			ClosedAt:      opened.Add(8 * time.Hour),
+			ReviewRounds:  2,
+			Reviewers:     []string{"bob"},
+		},
+	}
+	if !reflect.DeepEqual(latencies, want) {
+		t.Errorf("latencies = %+v, want %+v", latencies, want)
+	}
+}
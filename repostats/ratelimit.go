@@ -0,0 +1,360 @@
+package repostats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy controls how ClientGQL reacts when GitHub rate limits a query.
+type RateLimitPolicy struct {
+	// MaxRetries is how many times a rate limited query is retried before giving up.
+	MaxRetries int
+	// MaxWait is the longest single wait accepted, longer waits fail immediately.
+	MaxWait time.Duration
+	// MinRemaining makes queries wait for the reset once fewer points are left, 0 disables it.
+	MinRemaining int
+	// BaseBackoff is the first wait after a secondary limit without a Retry-After header,
+	// doubled on every following attempt.
+	BaseBackoff time.Duration
+	// OnWait, if set, is called before every wait.
+	OnWait func(RateLimitWait)
+}
+
+// RateLimitWait describes a wait triggered by the rate limit policy.
+type RateLimitWait struct {
+	Attempt   int
+	Wait      time.Duration
+	ResetAt   time.Time
+	Secondary bool
+	// Proactive is true when waiting because MinRemaining was reached, not after a failed query.
+	Proactive bool
+}
+
+// DefaultRateLimitPolicy waits up to the primary limit reset, which happens at most an hour later.
+var DefaultRateLimitPolicy = RateLimitPolicy{
+	MaxRetries:  5,
+	MaxWait:     65 * time.Minute,
+	BaseBackoff: time.Minute,
+}
+
+// RateLimitError is returned when a query is still rate limited after the policy retries.
+type RateLimitError struct {
+	// Secondary is true for secondary (abuse) limits, false for the primary points budget.
+	Secondary  bool
+	ResetAt    time.Time
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "primary"
+	if e.Secondary {
+		kind = "secondary"
+	}
+	if !e.ResetAt.IsZero() {
+		return fmt.Sprintf("%s rate limit exceeded, resets at %s: %v", kind, e.ResetAt.Format(time.RFC3339), e.Err)
+	}
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s: %v", kind, e.RetryAfter, e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// wait returns how long to wait before retrying, without jitter.
+func (e *RateLimitError) wait(now time.Time, attempt int, base time.Duration) time.Duration {
+	switch {
+	case e.RetryAfter > 0:
+		return e.RetryAfter
+	case !e.ResetAt.IsZero():
+		return max(e.ResetAt.Sub(now), 0)
+	default:
+		return base << attempt
+	}
+}
+
+type gqlResponseError struct {
+	Type    string
+	Message string
+	Path    []any
+}
+
+// responseInfo collects what the transport sees of a single GraphQL response,
+// as the GraphQL client only reports the first error message.
+type responseInfo struct {
+	StatusCode int
+	Header     http.Header
+	Errors     []gqlResponseError
+	Message    string
+}
+
+type responseInfoKey struct{}
+
+func withResponseInfo(ctx context.Context, info *responseInfo) context.Context {
+	return context.WithValue(ctx, responseInfoKey{}, info)
+}
+
+// rateLimitTransport records the response of every request carrying a responseInfo.
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	info, ok := req.Context().Value(responseInfoKey{}).(*responseInfo)
+	if !ok {
+		return resp, nil
+	}
+
+	info.StatusCode = resp.StatusCode
+	info.Header = resp.Header
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, nil
+	}
+
+	var out struct {
+		Message string
+		Errors  []gqlResponseError
+	}
+	if json.Unmarshal(body, &out) == nil {
+		info.Errors = out.Errors
+		info.Message = out.Message
+	}
+
+	return resp, nil
+}
+
+// rateLimitError returns a RateLimitError if the response was rate limited.
+func (info *responseInfo) rateLimitError(err error) *RateLimitError {
+	if info.StatusCode == 0 {
+		return nil
+	}
+
+	resetAt := time.Time{}
+	if reset, convErr := strconv.ParseInt(info.Header.Get("X-RateLimit-Reset"), 10, 64); convErr == nil {
+		resetAt = time.Unix(reset, 0)
+	}
+
+	retryAfter := time.Duration(0)
+	if seconds, convErr := strconv.Atoi(info.Header.Get("Retry-After")); convErr == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	for _, gqlErr := range info.Errors {
+		if gqlErr.Type == "RATE_LIMITED" {
+			return &RateLimitError{ResetAt: resetAt, RetryAfter: retryAfter, Err: err}
+		}
+	}
+
+	if info.StatusCode != http.StatusForbidden && info.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	message := strings.ToLower(info.Message)
+	if retryAfter > 0 || strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse") {
+		return &RateLimitError{Secondary: true, RetryAfter: retryAfter, Err: err}
+	}
+
+	if info.Header.Get("X-RateLimit-Remaining") == "0" {
+		return &RateLimitError{ResetAt: resetAt, Err: err}
+	}
+
+	return nil
+}
+
+// rateLimiter keeps the last rate limit reported by GitHub and applies the policy.
+type rateLimiter struct {
+	mu     sync.Mutex
+	policy RateLimitPolicy
+	last   RateLimit
+	// budget, if set, replaces last for the proactive wait, as with a token pool
+	// the last response only tells about one of the tokens.
+	budget func() (RateLimit, bool)
+}
+
+func newRateLimiter(policy RateLimitPolicy) *rateLimiter {
+	return &rateLimiter{policy: policy}
+}
+
+func (l *rateLimiter) observe(header http.Header) {
+	if header == nil || header.Get("X-RateLimit-Remaining") == "" {
+		return
+	}
+
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	resetAt := time.Unix(reset, 0)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cost := 0
+	if resetAt.Equal(l.last.ResetAt) {
+		// points used since the previous response in the same window
+		cost = max(used-(l.last.Limit-l.last.Remaining), 0)
+	}
+
+	l.last = RateLimit{
+		Limit:     limit,
+		Cost:      cost,
+		Remaining: remaining,
+		ResetAt:   resetAt,
+	}
+}
+
+func (l *rateLimiter) snapshot() RateLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+// proactiveWait returns how long to wait before sending a query to keep MinRemaining points.
+func (l *rateLimiter) proactiveWait(now time.Time) (time.Duration, time.Time) {
+	last := l.snapshot()
+	if l.budget != nil {
+		var ok bool
+		if last, ok = l.budget(); !ok {
+			return 0, time.Time{}
+		}
+	}
+
+	if l.policy.MinRemaining <= 0 || last.ResetAt.IsZero() || last.Remaining >= l.policy.MinRemaining {
+		return 0, time.Time{}
+	}
+
+	if !now.Before(last.ResetAt) {
+		return 0, time.Time{}
+	}
+
+	return last.ResetAt.Sub(now), last.ResetAt
+}
+
+// jitter adds up to 10% of d, plus up to a second, so that concurrent queries don't retry together.
+func jitter(d time.Duration) time.Duration {
+	return d + rand.N(d/10+time.Second)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// queryWithRateLimit runs a query applying the rate limit policy.
+func (c *ClientGQL) queryWithRateLimit(ctx context.Context, q any, variables map[string]any) error {
+	policy := c.limiter.policy
+
+	if wait, resetAt := c.limiter.proactiveWait(time.Now()); wait > 0 {
+		wait = jitter(wait)
+		if wait > policy.MaxWait {
+			return &RateLimitError{ResetAt: resetAt, Err: errors.New("remaining points below the configured minimum")}
+		}
+		c.opts.logger.Info("remaining points below minimum, waiting", "operation", "query", "wait", wait, "resetAt", resetAt)
+		if policy.OnWait != nil {
+			policy.OnWait(RateLimitWait{Wait: wait, ResetAt: resetAt, Proactive: true})
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+
+	return c.retryRateLimited(ctx, "query", func() error {
+		info := &responseInfo{}
+		err := c.ghClient.Query(withResponseInfo(ctx, info), q, variables)
+		c.limiter.observe(info.Header)
+
+		if err == nil {
+			return nil
+		}
+
+		if rateLimitErr := info.rateLimitError(err); rateLimitErr != nil {
+			return rateLimitErr
+		}
+		if len(info.Errors) > 0 {
+			return newGraphQLError(info.Errors[0])
+		}
+		return err
+	})
+}
+
+// retryRateLimited calls send until it doesn't fail with a RateLimitError, waiting as the
+// rate limit policy says, up to its retries. REST requests use it to share the policy of the
+// queries, restError telling their rate limits apart.
+func (c *ClientGQL) retryRateLimited(ctx context.Context, operation string, send func() error) error {
+	policy := c.limiter.policy
+
+	for attempt := 0; ; attempt++ {
+		err := send()
+
+		var rateLimitErr *RateLimitError
+		if !errors.As(err, &rateLimitErr) {
+			return err
+		}
+
+		if attempt >= policy.MaxRetries {
+			return rateLimitErr
+		}
+
+		wait := jitter(rateLimitErr.wait(time.Now(), attempt, policy.BaseBackoff))
+		if wait > policy.MaxWait {
+			return rateLimitErr
+		}
+
+		c.opts.logger.Warn("rate limited, waiting", "operation", operation,
+			"attempt", attempt+1, "wait", wait, "secondary", rateLimitErr.Secondary, "resetAt", rateLimitErr.ResetAt)
+
+		if policy.OnWait != nil {
+			policy.OnWait(RateLimitWait{
+				Attempt:   attempt + 1,
+				Wait:      wait,
+				ResetAt:   rateLimitErr.ResetAt,
+				Secondary: rateLimitErr.Secondary,
+			})
+		}
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// ObservedRateLimit returns the rate limit reported by the last GraphQL response,
+// without spending a query like GetCurrentLimits does.
+func (c *ClientGQL) ObservedRateLimit() RateLimit {
+	return c.limiter.snapshot()
+}
@@ -2,10 +2,10 @@ package repostats
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/emanuelef/github-repo-activity-stats/stats"
@@ -13,19 +13,25 @@ import (
 )
 
 const (
-	apiGHUrl = "https://api.github.com"
-	rawGHUrl = "https://raw.githubusercontent.com"
+	apiGHUrl     = "https://api.github.com"
+	rawGHUrl     = "https://raw.githubusercontent.com"
+	graphqlGHUrl = "https://api.github.com/graphql"
 )
 
 type Client struct {
 	restyClient *resty.Client
+	opts        *options
 }
 
-func NewClient(transport *http.RoundTripper) *Client {
-	restyClient := resty.New()
-	restyClient.SetTransport(*transport)
+func NewClient(transport *http.RoundTripper, opts ...Option) *Client {
+	o := newOptions(opts)
 
-	return &Client{restyClient: restyClient}
+	base := o.transport
+	if transport != nil {
+		base = *transport
+	}
+
+	return &Client{restyClient: o.newRestyClient(base), opts: o}
 }
 
 func (c *Client) getStarsHistory(ghRepo string, totalStars int) (stats.StarsHistory, error) {
@@ -36,7 +42,7 @@ func (c *Client) getStarsHistory(ghRepo string, totalStars int) (stats.StarsHist
 
 	res := [](map[string]any){}
 	restyReq := c.restyClient.R().SetResult(&res).SetHeader("Accept", "application/vnd.github.star+json")
-	apiGithubUrl := fmt.Sprintf("%s/repos/%s/stargazers", apiGHUrl, ghRepo)
+	apiGithubUrl := fmt.Sprintf("%s/repos/%s/stargazers", c.opts.restURL, ghRepo)
 
 	// The stargazer endpoint allows only to reach page 400, and a maximum 100 results per page
 	// It also doesn't seem to support sorting in reverse order
@@ -44,6 +50,12 @@ func (c *Client) getStarsHistory(ghRepo string, totalStars int) (stats.StarsHist
 	perPage := strconv.Itoa(100)
 	page := (totalStars / 100) + 1
 
+	// the latest stars can't be reached, the history is left empty as expected for such repos
+	if page > stargazersPagesLimit {
+		c.opts.logger.Warn("latest stars over the pages limit", "repo", ghRepo, "operation", "getStarsHistory", "page", page)
+		return result, nil
+	}
+
 	for i := 0; i < 2; i++ {
 
 		currentPage := page - i
@@ -58,23 +70,27 @@ func (c *Client) getStarsHistory(ghRepo string, totalStars int) (stats.StarsHist
 				"per_page": perPage,
 			}).Get(apiGithubUrl)
 
+		if err != nil {
+			c.opts.logger.Error("request failed", "repo", ghRepo, "operation", "getStarsHistory", "error", err)
+			return result, partialResult(err)
+		}
+
 		if resp.StatusCode() == http.StatusUnprocessableEntity {
-			log.Println("Request over limit")
+			c.opts.logger.Warn("request over limit", "repo", ghRepo, "operation", "getStarsHistory", "page", currentPage)
+			return result, nil
 		}
 
-		if err != nil {
-			log.Println(err)
+		if !resp.IsSuccess() {
+			return result, partialResult(restError(resp, ghRepo))
 		}
 
 		if resp.IsSuccess() {
 			if len(res) == 0 {
-				log.Println("No stars")
+				c.opts.logger.Debug("no stars", "repo", ghRepo, "operation", "getStarsHistory")
 				return result, nil
 			}
 
-			result.LastStarDate, _ = time.Parse(time.RFC3339, res[0]["starred_at"].(string))
-
-			currentTime := time.Now()
+			currentTime := c.opts.clock.Now()
 
 			slices.Reverse(res)
 			for _, star := range res {
@@ -84,6 +100,15 @@ func (c *Client) getStarsHistory(ghRepo string, totalStars int) (stats.StarsHist
 				if err == nil {
 					days := currentTime.Sub(output).Hours() / 24
 
+					// starred after the clock time
+					if days < 0 {
+						continue
+					}
+
+					if result.LastStarDate.IsZero() {
+						result.LastStarDate = output
+					}
+
 					if days < 1 {
 						result.AddedLast24H += 1
 					}
@@ -112,7 +137,11 @@ func (c *Client) GetAllStats(ghRepo string) (*stats.RepoStats, error) {
 	res := make(map[string]any)
 	restyReq := c.restyClient.R().SetResult(&res)
 
-	apiGithubUrl := fmt.Sprintf("%s/repos/%s", apiGHUrl, ghRepo)
+	apiGithubUrl := fmt.Sprintf("%s/repos/%s", c.opts.restURL, ghRepo)
+
+	if len(strings.Split(ghRepo, "/")) != 2 {
+		return nil, invalidRepoError(ghRepo)
+	}
 
 	resp, err := restyReq.Get(apiGithubUrl)
 	if err != nil {
@@ -120,8 +149,8 @@ func (c *Client) GetAllStats(ghRepo string) (*stats.RepoStats, error) {
 	}
 
 	if !resp.IsSuccess() {
-		log.Println("Error getting repo infos")
-		return nil, fmt.Errorf("%s Error getting repo infos", resp.Status())
+		c.opts.logger.Error("getting repo infos failed", "repo", ghRepo, "operation", "GetAllStats", "status", resp.StatusCode())
+		return nil, restError(resp, ghRepo)
 	}
 
 	language, ok := res["language"].(string)
@@ -140,7 +169,10 @@ func (c *Client) GetAllStats(ghRepo string) (*stats.RepoStats, error) {
 		DefaultBranch: res["default_branch"].(string),
 	}
 
-	result.StarsHistory, _ = c.getStarsHistory(ghRepo, result.Stars)
+	result.StarsHistory, err = c.getStarsHistory(ghRepo, result.Stars)
+	if err != nil {
+		return &result, err
+	}
 
 	return &result, nil
 }
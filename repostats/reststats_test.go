@@ -0,0 +1,60 @@
+package repostats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emanuelef/github-repo-activity-stats/repostats/ghfake"
+)
+
+func TestGetAllStatsStarsHistory(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		stars         int
+		starsInterval time.Duration
+		wantLast24H   int
+		wantLast30d   int
+		wantLastStar  bool
+	}{
+		// one star per hour for the last 250 hours, only the last two pages are read
+		{name: "recent stars", stars: 250, starsInterval: time.Hour, wantLast24H: 24, wantLast30d: 150, wantLastStar: true},
+		// the latest stars are past the 400 pages served by the endpoint
+		{name: "over the pages limit", stars: 40_050, starsInterval: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := now.Add(-time.Duration(tt.stars-1) * tt.starsInterval)
+			srv := ghfake.NewServer(&ghfake.Repo{
+				Owner:         "octo",
+				Name:          "repo",
+				CreatedAt:     start.AddDate(-1, 0, 0),
+				DefaultBranch: "main",
+				Stargazers:    ghfake.GenerateStargazers(tt.stars, start, now),
+			})
+			defer srv.Close()
+
+			client := NewClient(nil, WithEnterpriseServer(srv.URL), WithClock(FixedClock(now)))
+
+			result, err := client.GetAllStats("octo/repo")
+			if err != nil {
+				t.Fatalf("GetAllStats() error = %v", err)
+			}
+
+			if result.Stars != tt.stars {
+				t.Errorf("Stars = %d, want %d", result.Stars, tt.stars)
+			}
+			if result.StarsHistory.AddedLast30d != tt.wantLast30d {
+				t.Errorf("AddedLast30d = %d, want %d", result.StarsHistory.AddedLast30d, tt.wantLast30d)
+			}
+			if result.StarsHistory.AddedLast24H != tt.wantLast24H {
+				t.Errorf("AddedLast24H = %d, want %d", result.StarsHistory.AddedLast24H, tt.wantLast24H)
+			}
+			if !result.StarsHistory.LastStarDate.IsZero() != tt.wantLastStar {
+				t.Errorf("LastStarDate = %v, want set %t", result.StarsHistory.LastStarDate, tt.wantLastStar)
+			}
+		})
+	}
+}
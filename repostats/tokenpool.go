@@ -0,0 +1,492 @@
+package repostats
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credential is a source of GitHub tokens used by a TokenPool.
+type Credential interface {
+	// Name identifies the credential in usage reports, it must not reveal the token.
+	Name() string
+	// Token returns the token to send, refreshing it when needed.
+	Token(ctx context.Context) (string, error)
+}
+
+type staticToken struct {
+	name  string
+	token string
+}
+
+// StaticToken returns a Credential for a personal access token.
+// When name is empty the credential is named after the last 4 characters of the token.
+func StaticToken(name, token string) Credential {
+	if name == "" {
+		name = "token-" + token[max(len(token)-4, 0):]
+	}
+	return &staticToken{name: name, token: token}
+}
+
+func (t *staticToken) Name() string {
+	return t.name
+}
+
+func (t *staticToken) Token(context.Context) (string, error) {
+	return t.token, nil
+}
+
+// AppInstallation is a Credential for a GitHub App installation.
+// Installation tokens are obtained with a JWT signed by the App private key
+// and refreshed a few minutes before they expire.
+type AppInstallation struct {
+	AppID          int64
+	InstallationID int64
+	// APIURL is the REST API the tokens are requested to, https://api.github.com if empty.
+	APIURL string
+	// HTTPClient sends the token requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	key *rsa.PrivateKey
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// installationTokenMargin is how long before the expiry an installation token is refreshed.
+const installationTokenMargin = 5 * time.Minute
+
+// NewAppInstallation returns a Credential for an installation of the App appID,
+// privateKeyPEM is the PEM encoded private key generated in the App settings.
+func NewAppInstallation(appID, installationID int64, privateKeyPEM []byte) (*AppInstallation, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("app private key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("parsing app private key: %w", err)
+		}
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("app private key is not an RSA key")
+		}
+		key = rsaKey
+	}
+
+	return &AppInstallation{AppID: appID, InstallationID: installationID, key: key}, nil
+}
+
+func (a *AppInstallation) Name() string {
+	return fmt.Sprintf("app-%d-installation-%d", a.AppID, a.InstallationID)
+}
+
+func (a *AppInstallation) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Until(a.expiresAt) > installationTokenMargin {
+		return a.token, nil
+	}
+
+	jwt, err := a.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	apiURL := a.APIURL
+	if apiURL == "" {
+		apiURL = apiGHUrl
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/app/installations/%d/access_tokens", strings.TrimSuffix(apiURL, "/"), a.InstallationID), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("installation token request for %s failed with status %d: %s", a.Name(), resp.StatusCode, body)
+	}
+
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decoding installation token for %s: %w", a.Name(), err)
+	}
+
+	a.token = out.Token
+	a.expiresAt = out.ExpiresAt
+
+	return a.token, nil
+}
+
+// jwt returns the App JWT, valid for 9 minutes and backdated by one to allow for clock drift.
+func (a *AppInstallation) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(a.AppID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// TokenUsage reports how a credential of a TokenPool has been used.
+type TokenUsage struct {
+	Name string
+	// Requests is the number of requests sent with the credential.
+	Requests int
+	// RateLimited is the number of requests rejected because the credential was out of budget.
+	RateLimited int
+	// GraphQL, Core and Search are the last rate limits reported by GitHub for each resource,
+	// zero until a response for that resource is seen.
+	GraphQL RateLimit
+	Core    RateLimit
+	Search  RateLimit
+}
+
+// TokenPool spreads requests over several credentials, sending each one with the credential
+// that has the most budget left for the resource it consumes. A credential rejected for its rate
+// limit is set aside until its reset and the request is retried with another one.
+type TokenPool struct {
+	mu          sync.Mutex
+	credentials []*pooledCredential
+}
+
+type pooledCredential struct {
+	credential  Credential
+	requests    int
+	rateLimited int
+	limits      map[string]RateLimit
+	used        map[string]int
+}
+
+// rate limit resources, as reported by the X-RateLimit-Resource header
+const (
+	resourceGraphQL = "graphql"
+	resourceCore    = "core"
+	resourceSearch  = "search"
+)
+
+// NewTokenPool returns a pool of credentials, to be used with WithTokenPool.
+func NewTokenPool(credentials ...Credential) *TokenPool {
+	p := &TokenPool{}
+	for _, credential := range credentials {
+		p.credentials = append(p.credentials, &pooledCredential{
+			credential: credential,
+			limits:     map[string]RateLimit{},
+			used:       map[string]int{},
+		})
+	}
+	return p
+}
+
+// Usage returns the usage of every credential, in the order they were given.
+func (p *TokenPool) Usage() []TokenUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	usage := make([]TokenUsage, len(p.credentials))
+	for i, pc := range p.credentials {
+		usage[i] = TokenUsage{
+			Name:        pc.credential.Name(),
+			Requests:    pc.requests,
+			RateLimited: pc.rateLimited,
+			GraphQL:     pc.limits[resourceGraphQL],
+			Core:        pc.limits[resourceCore],
+			Search:      pc.limits[resourceSearch],
+		}
+	}
+	return usage
+}
+
+// requestResource guesses the rate limit resource a request consumes.
+func requestResource(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return resourceGraphQL
+	case strings.Contains(req.URL.Path, "/search/"):
+		return resourceSearch
+	default:
+		return resourceCore
+	}
+}
+
+// available returns the budget left for resource, credentials never used for it count as full.
+func (pc *pooledCredential) available(resource string, now time.Time) (int, bool) {
+	limit, ok := pc.limits[resource]
+	if !ok || !now.Before(limit.ResetAt) {
+		return int(^uint(0) >> 1), true
+	}
+	return limit.Remaining, limit.Remaining > 0
+}
+
+// pick returns the credential with the most budget left for resource, skipping the ones in tried.
+// When all of them are out of budget, the one resetting first is returned.
+func (p *TokenPool) pick(resource string, tried map[int]bool) (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	best, bestRemaining := -1, -1
+	var earliestReset time.Time
+	earliest := -1
+
+	for i, pc := range p.credentials {
+		if tried[i] {
+			continue
+		}
+		remaining, ok := pc.available(resource, now)
+		if ok && remaining > bestRemaining {
+			best, bestRemaining = i, remaining
+		}
+		if resetAt := pc.limits[resource].ResetAt; earliest == -1 || resetAt.Before(earliestReset) {
+			earliest, earliestReset = i, resetAt
+		}
+	}
+
+	if best == -1 {
+		return earliest, false
+	}
+
+	// count the request in flight so that concurrent requests spread over the credentials
+	if limit, ok := p.credentials[best].limits[resource]; ok && limit.Remaining > 0 {
+		limit.Remaining--
+		p.credentials[best].limits[resource] = limit
+	}
+
+	return best, true
+}
+
+// observe records the rate limit reported in a response sent with the credential i.
+func (p *TokenPool) observe(i int, resource string, header http.Header, rateLimited bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc := p.credentials[i]
+	pc.requests++
+	if rateLimited {
+		pc.rateLimited++
+	}
+
+	if header.Get("X-RateLimit-Remaining") == "" {
+		return
+	}
+
+	if r := header.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	resetAt := time.Unix(reset, 0)
+
+	cost := 0
+	if previous, ok := pc.limits[resource]; ok && resetAt.Equal(previous.ResetAt) {
+		// points used since the previous response in the same window
+		cost = max(used-pc.used[resource], 0)
+	}
+
+	pc.used[resource] = used
+	pc.limits[resource] = RateLimit{
+		Limit:     limit,
+		Cost:      cost,
+		Remaining: remaining,
+		ResetAt:   resetAt,
+	}
+}
+
+// graphQLBudget returns the GraphQL rate limit of the credential with the most points left,
+// false until every credential has reported one.
+func (p *TokenPool) graphQLBudget() (RateLimit, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	best := RateLimit{Remaining: -1}
+	for _, pc := range p.credentials {
+		limit, ok := pc.limits[resourceGraphQL]
+		if !ok || !now.Before(limit.ResetAt) {
+			return RateLimit{}, false
+		}
+		if limit.Remaining > best.Remaining {
+			best = limit
+		}
+	}
+	return best, len(p.credentials) > 0
+}
+
+type poolCredentialKey struct{}
+
+// withPoolCredential forces the requests made with ctx to use the credential i.
+func withPoolCredential(ctx context.Context, i int) context.Context {
+	return context.WithValue(ctx, poolCredentialKey{}, i)
+}
+
+// tokenPoolTransport authenticates the requests with the credentials of a pool.
+type tokenPoolTransport struct {
+	pool *TokenPool
+	base http.RoundTripper
+}
+
+func (p *TokenPool) transport(base http.RoundTripper) http.RoundTripper {
+	return &tokenPoolTransport{pool: p, base: base}
+}
+
+func (t *tokenPoolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if len(t.pool.credentials) == 0 {
+		return base.RoundTrip(req)
+	}
+
+	resource := requestResource(req)
+	forced, isForced := req.Context().Value(poolCredentialKey{}).(int)
+	tried := map[int]bool{}
+
+	for {
+		i, ok := forced, true
+		if !isForced {
+			i, ok = t.pool.pick(resource, tried)
+		}
+		tried[i] = true
+
+		token, err := t.pool.credentials[i].credential.Token(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		attempt := req.Clone(req.Context())
+		if req.Body != nil && req.GetBody != nil {
+			if attempt.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		attempt.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := base.RoundTrip(attempt)
+		if err != nil {
+			return resp, err
+		}
+
+		rateLimited := isRateLimitedResponse(resp)
+		t.pool.observe(i, resource, resp.Header, rateLimited)
+
+		// retry with another credential, unless none has budget left or the body can't be sent again
+		if !rateLimited || isForced || !ok || len(tried) == len(t.pool.credentials) || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// isRateLimitedResponse tells if the response was rejected for the primary rate limit,
+// GraphQL reports it with a 200 and a RATE_LIMITED error.
+func isRateLimitedResponse(resp *http.Response) bool {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return false
+	}
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	return bytes.Contains(body, []byte(`"RATE_LIMITED"`))
+}
+
+// GetTokenPoolLimits queries the rate limit of every credential of the pool set with
+// WithTokenPool, like GetCurrentLimits does for a single token, and returns the pool usage.
+func (c *ClientGQL) GetTokenPoolLimits(ctx context.Context) ([]TokenUsage, error) {
+	if c.opts.tokenPool == nil {
+		return nil, errors.New("no token pool configured")
+	}
+
+	var query struct {
+		RateLimit struct {
+			Limit     int
+			Cost      int
+			Remaining int
+			ResetAt   time.Time
+		}
+	}
+
+	for i, pc := range c.opts.tokenPool.credentials {
+		// not cached, the limits are always fetched
+		if err := c.queryWithRateLimit(withPoolCredential(ctx, i), &query, nil); err != nil {
+			c.opts.logger.Error("query failed", "operation", "GetTokenPoolLimits", "credential", pc.credential.Name(), "error", err)
+			return c.opts.tokenPool.Usage(), err
+		}
+	}
+
+	return c.opts.tokenPool.Usage(), nil
+}
@@ -3,8 +3,10 @@ package repostats
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/emanuelef/github-repo-activity-stats/stats"
@@ -35,3 +37,85 @@ func WriteStarsHistoryCSV(filename string, history []stats.StarsPerDay) {
 		})
 	}
 }
+
+// ReadStarsHistoryCSV reads back a stars history written by WriteStarsHistoryCSV.
+func ReadStarsHistoryCSV(filename string) ([]stats.StarsPerDay, error) {
+	inputFile, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer inputFile.Close()
+
+	rows, err := csv.NewReader(inputFile).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	history := []stats.StarsPerDay{}
+
+	for i, row := range rows {
+		// skip header
+		if i == 0 {
+			continue
+		}
+
+		if len(row) < 3 {
+			return nil, fmt.Errorf("invalid row %d in %s", i, filename)
+		}
+
+		day, err := time.Parse("02-01-2006", row[0])
+		if err != nil {
+			return nil, err
+		}
+
+		stars, err := strconv.Atoi(row[1])
+		if err != nil {
+			return nil, err
+		}
+
+		totalStars, err := strconv.Atoi(row[2])
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, stats.StarsPerDay{
+			Day:        stats.JSONDay(day),
+			Stars:      stars,
+			TotalStars: totalStars,
+		})
+	}
+
+	return history, nil
+}
+
+// WriteContributorFunnelCSV writes a contributor funnel, as returned by GetContributorFunnel, as CSV.
+func WriteContributorFunnelCSV(w io.Writer, funnel []stats.ContributorFunnelMonth) error {
+	csvWriter := csv.NewWriter(w)
+
+	headerRow := []string{
+		"month", "first-time-authors", "first-time-merged", "returned", "merged-prs", "external-merged-prs", "external-merged-share",
+	}
+
+	if err := csvWriter.Write(headerRow); err != nil {
+		return err
+	}
+
+	for _, v := range funnel {
+		err := csvWriter.Write([]string{
+			time.Time(v.Month).Format("02-01-2006"),
+			strconv.Itoa(v.FirstTimeAuthors),
+			strconv.Itoa(v.FirstTimeMerged),
+			strconv.Itoa(v.Returned),
+			strconv.Itoa(v.MergedPRs),
+			strconv.Itoa(v.ExternalMergedPRs),
+			strconv.FormatFloat(v.ExternalMergedShare, 'f', 4, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,248 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a calendar period the daily timelines can be resampled to
+type Period int
+
+const (
+	Week    Period = iota + 1 // ISO week, starting on Monday
+	Month                     // calendar month
+	Quarter                   // calendar quarter, starting in January, April, July and October
+	Year                      // calendar year
+)
+
+func (p Period) String() string {
+	switch p {
+	case Week:
+		return "week"
+	case Month:
+		return "month"
+	case Quarter:
+		return "quarter"
+	case Year:
+		return "year"
+	}
+	return fmt.Sprintf("Period(%d)", int(p))
+}
+
+// Start returns the first day of the period containing t, in the location of t
+func (p Period) Start(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	switch p {
+	case Week:
+		// Monday is the first day of the ISO week
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case Quarter:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, t.Location())
+	case Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// DailyBucket is implemented by the per-day timeline types, and by any other timeline
+// type to resample with Resample and Trim
+type DailyBucket[T any] interface {
+	// Date returns the day of the bucket
+	Date() time.Time
+	// RollUp adds next, a following day, to the bucket: deltas are summed and
+	// cumulative totals are the ones of next
+	RollUp(next T) T
+	// At returns the bucket moved to day
+	At(day time.Time) T
+}
+
+// Resample rolls a daily timeline up to period, each bucket being labelled with the first day
+// of its period. Deltas, like Stars or Opened, are summed, while cumulative totals, like
+// TotalStars or TotalCurrentlyOpen, and snapshots, like the backlog, are the ones of the last
+// day of the period in the timeline.
+//
+// CurrentlyOpen counts the items opened in the period that are still open, as it does for a day.
+func Resample[T DailyBucket[T]](timeline []T, period Period) []T {
+	result := []T{}
+
+	var currentStart time.Time
+	for i, day := range timeline {
+		start := period.Start(day.Date())
+
+		if i == 0 || !start.Equal(currentStart) {
+			result = append(result, day.At(start))
+			currentStart = start
+			continue
+		}
+
+		result[len(result)-1] = result[len(result)-1].RollUp(day)
+	}
+
+	return result
+}
+
+// Trim returns the days of the timeline from the day of from to the day of to, both included.
+// A zero from or to leaves that side open.
+func Trim[T DailyBucket[T]](timeline []T, from, to time.Time) []T {
+	result := []T{}
+
+	for _, day := range timeline {
+		date := day.Date()
+		if !from.IsZero() && date.Before(dayStart(from, date.Location())) {
+			continue
+		}
+		if !to.IsZero() && date.After(dayStart(to, date.Location())) {
+			continue
+		}
+		result = append(result, day)
+	}
+
+	return result
+}
+
+// dayStart returns the midnight starting the day of t in loc
+func dayStart(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func (t StarsPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t StarsPerDay) RollUp(next StarsPerDay) StarsPerDay {
+	t.Stars += next.Stars
+	t.TotalStars = next.TotalStars
+	return t
+}
+
+func (t StarsPerDay) At(day time.Time) StarsPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t EstimatedStarsPerDay) RollUp(next EstimatedStarsPerDay) EstimatedStarsPerDay {
+	t.StarsPerDay = t.StarsPerDay.RollUp(next.StarsPerDay)
+	t.Estimated = t.Estimated || next.Estimated
+	return t
+}
+
+func (t EstimatedStarsPerDay) At(day time.Time) EstimatedStarsPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t CommitsPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t CommitsPerDay) RollUp(next CommitsPerDay) CommitsPerDay {
+	t.Commits += next.Commits
+	t.TotalCommits = next.TotalCommits
+	return t
+}
+
+func (t CommitsPerDay) At(day time.Time) CommitsPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t IssuesPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t IssuesPerDay) RollUp(next IssuesPerDay) IssuesPerDay {
+	t.Opened += next.Opened
+	t.Closed += next.Closed
+	t.CurrentlyOpen += next.CurrentlyOpen
+	t.TotalOpened = next.TotalOpened
+	t.TotalClosed = next.TotalClosed
+	t.TotalCurrentlyOpen = next.TotalCurrentlyOpen
+	return t
+}
+
+func (t IssuesPerDay) At(day time.Time) IssuesPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t ForksPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t ForksPerDay) RollUp(next ForksPerDay) ForksPerDay {
+	t.Forks += next.Forks
+	t.TotalForks = next.TotalForks
+	return t
+}
+
+func (t ForksPerDay) At(day time.Time) ForksPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t PRsPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t PRsPerDay) RollUp(next PRsPerDay) PRsPerDay {
+	t.Opened += next.Opened
+	t.Merged += next.Merged
+	t.Closed += next.Closed
+	t.CurrentlyOpen += next.CurrentlyOpen
+	t.TotalOpened = next.TotalOpened
+	t.TotalMerged = next.TotalMerged
+	t.TotalClosed = next.TotalClosed
+	t.TotalCurrentlyOpen = next.TotalCurrentlyOpen
+	return t
+}
+
+func (t PRsPerDay) At(day time.Time) PRsPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t NewContributorsPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t NewContributorsPerDay) RollUp(next NewContributorsPerDay) NewContributorsPerDay {
+	t.NewContributors += next.NewContributors
+	t.TotalNewContributors = next.TotalNewContributors
+	return t
+}
+
+func (t NewContributorsPerDay) At(day time.Time) NewContributorsPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t NewReposPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t NewReposPerDay) RollUp(next NewReposPerDay) NewReposPerDay {
+	t.Count += next.Count
+	t.TotalSeen = next.TotalSeen
+	return t
+}
+
+func (t NewReposPerDay) At(day time.Time) NewReposPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t NewPRsPerDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t NewPRsPerDay) RollUp(next NewPRsPerDay) NewPRsPerDay {
+	t.Count += next.Count
+	t.TotalSeen = next.TotalSeen
+	return t
+}
+
+func (t NewPRsPerDay) At(day time.Time) NewPRsPerDay {
+	t.Day = JSONDay(day)
+	return t
+}
+
+func (t BacklogDay) Date() time.Time { return time.Time(t.Day) }
+
+func (t BacklogDay) RollUp(next BacklogDay) BacklogDay {
+	next.Day = t.Day
+	return next
+}
+
+func (t BacklogDay) At(day time.Time) BacklogDay {
+	t.Day = JSONDay(day)
+	return t
+}
@@ -0,0 +1,197 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// starsTimeline returns a star a day from the day of from to the day of to, starting from 100 stars.
+func starsTimeline(from, to time.Time) []StarsPerDay {
+	timeline := []StarsPerDay{}
+	for day, total := from, 100; !day.After(to); day = day.AddDate(0, 0, 1) {
+		total++
+		timeline = append(timeline, StarsPerDay{Day: JSONDay(day), Stars: 1, TotalStars: total})
+	}
+	return timeline
+}
+
+func TestPeriodStart(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skipf("no tz database: %v", err)
+	}
+
+	tests := []struct {
+		period Period
+		t      time.Time
+		want   time.Time
+	}{
+		{Week, date(2024, time.December, 25), date(2024, time.December, 23)},
+		{Week, date(2024, time.December, 23), date(2024, time.December, 23)},
+		{Week, date(2024, time.December, 29), date(2024, time.December, 23)}, // Sunday ends the ISO week
+		{Week, date(2025, time.January, 1), date(2024, time.December, 30)},  // ISO week 1 of 2025
+		{Month, date(2024, time.February, 29), date(2024, time.February, 1)},
+		{Quarter, date(2024, time.March, 31), date(2024, time.January, 1)},
+		{Quarter, date(2024, time.May, 15), date(2024, time.April, 1)},
+		{Quarter, date(2024, time.December, 31), date(2024, time.October, 1)},
+		{Year, date(2024, time.July, 4), date(2024, time.January, 1)},
+		{Month, time.Date(2024, time.April, 1, 0, 30, 0, 0, rome), time.Date(2024, time.April, 1, 0, 0, 0, 0, rome)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.period.Start(tt.t); !got.Equal(tt.want) || got.Location() != tt.want.Location() {
+			t.Errorf("%s.Start(%v) = %v, want %v", tt.period, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	// from a Wednesday of December 2024 to a Wednesday of April 2025, 99 days
+	timeline := starsTimeline(date(2024, time.December, 25), date(2025, time.April, 2))
+
+	bucket := func(day time.Time, stars, total int) StarsPerDay {
+		return StarsPerDay{Day: JSONDay(day), Stars: stars, TotalStars: total}
+	}
+
+	tests := []struct {
+		period Period
+		want   []StarsPerDay
+	}{
+		{
+			period: Month,
+			want: []StarsPerDay{
+				bucket(date(2024, time.December, 1), 7, 107),
+				bucket(date(2025, time.January, 1), 31, 138),
+				bucket(date(2025, time.February, 1), 28, 166),
+				bucket(date(2025, time.March, 1), 31, 197),
+				bucket(date(2025, time.April, 1), 2, 199),
+			},
+		},
+		{
+			period: Quarter,
+			want: []StarsPerDay{
+				bucket(date(2024, time.October, 1), 7, 107),
+				bucket(date(2025, time.January, 1), 90, 197),
+				bucket(date(2025, time.April, 1), 2, 199),
+			},
+		},
+		{
+			period: Year,
+			want: []StarsPerDay{
+				bucket(date(2024, time.January, 1), 7, 107),
+				bucket(date(2025, time.January, 1), 92, 199),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period.String(), func(t *testing.T) {
+			if got := Resample(timeline, tt.period); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resample() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("week", func(t *testing.T) {
+		got := Resample(timeline, Week)
+
+		if len(got) != 15 {
+			t.Fatalf("got %d weeks, want 15", len(got))
+		}
+
+		want := map[int]StarsPerDay{
+			0:  bucket(date(2024, time.December, 23), 5, 105), // partial, from Wednesday
+			1:  bucket(date(2024, time.December, 30), 7, 112), // across the new year
+			2:  bucket(date(2025, time.January, 6), 7, 119),
+			14: bucket(date(2025, time.March, 31), 3, 199), // partial, to Wednesday
+		}
+		for i, week := range want {
+			if got[i] != week {
+				t.Errorf("week %d = %v, want %v", i, got[i], week)
+			}
+		}
+	})
+}
+
+func TestResampleTotals(t *testing.T) {
+	day := func(d int) JSONDay { return JSONDay(date(2024, time.March, d)) }
+
+	// March 4 is a Monday
+	issues := []IssuesPerDay{
+		{Day: day(4), Opened: 3, Closed: 1, TotalOpened: 13, TotalClosed: 6, CurrentlyOpen: 2, TotalCurrentlyOpen: 7},
+		{Day: day(5), Opened: 0, Closed: 2, TotalOpened: 13, TotalClosed: 8, CurrentlyOpen: 0, TotalCurrentlyOpen: 5},
+		{Day: day(10), Opened: 4, Closed: 0, TotalOpened: 17, TotalClosed: 8, CurrentlyOpen: 1, TotalCurrentlyOpen: 9},
+		{Day: day(11), Opened: 1, Closed: 1, TotalOpened: 18, TotalClosed: 9, CurrentlyOpen: 1, TotalCurrentlyOpen: 9},
+	}
+
+	wantIssues := []IssuesPerDay{
+		// CurrentlyOpen counts the issues of the week still open, TotalCurrentlyOpen is the one of Sunday
+		{Day: day(4), Opened: 7, Closed: 3, TotalOpened: 17, TotalClosed: 8, CurrentlyOpen: 3, TotalCurrentlyOpen: 9},
+		{Day: day(11), Opened: 1, Closed: 1, TotalOpened: 18, TotalClosed: 9, CurrentlyOpen: 1, TotalCurrentlyOpen: 9},
+	}
+	if got := Resample(issues, Week); !reflect.DeepEqual(got, wantIssues) {
+		t.Errorf("Resample(issues) = %+v, want %+v", got, wantIssues)
+	}
+
+	// the backlog is a snapshot, the one of the last day
+	backlog := []BacklogDay{
+		{Day: day(4), Open: 10, Under7d: 2, Older: 8},
+		{Day: day(6), Open: 12, Under7d: 4, Older: 8},
+		{Day: day(9), Open: 11, Under7d: 3, Older: 8},
+	}
+	wantBacklog := []BacklogDay{{Day: day(4), Open: 11, Under7d: 3, Older: 8}}
+	if got := Resample(backlog, Week); !reflect.DeepEqual(got, wantBacklog) {
+		t.Errorf("Resample(backlog) = %+v, want %+v", got, wantBacklog)
+	}
+
+	if got := Resample([]StarsPerDay{}, Month); len(got) != 0 {
+		t.Errorf("Resample(empty) = %v, want no buckets", got)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	timeline := starsTimeline(date(2025, time.January, 1), date(2025, time.January, 10))
+	newYork := time.FixedZone("UTC-5", -5*60*60)
+
+	tests := []struct {
+		name        string
+		from, to    time.Time
+		first, last time.Time
+	}{
+		{"open", time.Time{}, time.Time{}, date(2025, time.January, 1), date(2025, time.January, 10)},
+		{"both included", date(2025, time.January, 3), date(2025, time.January, 5), date(2025, time.January, 3), date(2025, time.January, 5)},
+		{"time of day", date(2025, time.January, 3).Add(18 * time.Hour), date(2025, time.January, 5).Add(time.Hour), date(2025, time.January, 3), date(2025, time.January, 5)},
+		{"open from", time.Time{}, date(2025, time.January, 2), date(2025, time.January, 1), date(2025, time.January, 2)},
+		{"open to", date(2025, time.January, 9), time.Time{}, date(2025, time.January, 9), date(2025, time.January, 10)},
+		{"beyond the timeline", date(2024, time.December, 1), date(2025, time.February, 1), date(2025, time.January, 1), date(2025, time.January, 10)},
+		// 23:30 in New York is already the next day in the UTC timeline
+		{"other location", time.Date(2025, time.January, 2, 23, 30, 0, 0, newYork), time.Time{}, date(2025, time.January, 3), date(2025, time.January, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Trim(timeline, tt.from, tt.to)
+			if len(got) == 0 {
+				t.Fatal("Trim() returned no days")
+			}
+			if first := got[0].Date(); !first.Equal(tt.first) {
+				t.Errorf("first day = %v, want %v", first, tt.first)
+			}
+			if last := got[len(got)-1].Date(); !last.Equal(tt.last) {
+				t.Errorf("last day = %v, want %v", last, tt.last)
+			}
+			if days := int(tt.last.Sub(tt.first)/(24*time.Hour)) + 1; len(got) != days {
+				t.Errorf("got %d days, want %d", len(got), days)
+			}
+		})
+	}
+
+	if got := Trim(timeline, date(2025, time.January, 5), date(2025, time.January, 4)); len(got) != 0 {
+		t.Errorf("Trim() with from after to = %v, want no days", got)
+	}
+}
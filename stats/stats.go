@@ -24,6 +24,21 @@ type StarsPerDay struct {
 	TotalStars int
 }
 
+// EstimatedStarsPerDay is a StarsPerDay whose values may be interpolated between sampled points.
+type EstimatedStarsPerDay struct {
+	StarsPerDay
+	// Estimated is false when Stars and TotalStars were counted from the stargazers of the day.
+	Estimated bool
+}
+
+// EstimatedStarsHistory is a stars history sampled within a requests budget
+type EstimatedStarsHistory struct {
+	Timeline []EstimatedStarsPerDay `json:"timeline"`
+	// FailedSamples are the positions, from 1 for the oldest star, of the first star of the
+	// samples that couldn't be fetched, the estimate being coarser around them
+	FailedSamples []int `json:"failedSamples"`
+}
+
 type StarsPerHour struct {
 	Hour       time.Time `json:"hour"`
 	Stars      int       `json:"stars"`
@@ -75,6 +90,10 @@ func (t StarsPerDay) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]any{t.Day, t.Stars, t.TotalStars})
 }
 
+func (t EstimatedStarsPerDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{t.Day, t.Stars, t.TotalStars, t.Estimated})
+}
+
 func (t CommitsPerDay) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]any{t.Day, t.Commits, t.TotalCommits})
 }
@@ -130,6 +149,217 @@ type ReleaseInfo struct {
 	TotalReleases int       `json:"totalReleases"` // Cumulative count at this point
 }
 
+// StargazerProfile is the public profile of a user who starred a repo
+type StargazerProfile struct {
+	Login         string    `json:"login"`
+	Name          string    `json:"name"`
+	Company       string    `json:"company"`
+	Location      string    `json:"location"`
+	Followers     int       `json:"followers"`
+	Repositories  int       `json:"repositories"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Organizations []string  `json:"organizations"`
+	StarredAt     time.Time `json:"starredAt"`
+}
+
+// NameCount is a value and the number of stargazers sharing it
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// AccountAgeBucket counts the stargazers whose account was at least MinDays old when starring
+// and younger than the MinDays of the next bucket
+type AccountAgeBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"minDays"`
+	Count   int    `json:"count"`
+}
+
+// AudienceReport summarizes who starred a repo
+type AudienceReport struct {
+	Stargazers       int                `json:"stargazers"`
+	TopCompanies     []NameCount        `json:"topCompanies"`
+	TopLocations     []NameCount        `json:"topLocations"`
+	TopOrganizations []NameCount        `json:"topOrganizations"`
+	AccountAge       []AccountAgeBucket `json:"accountAge"`
+	NewAccounts      int                `json:"newAccounts"`      // accounts created less than 30 days before starring
+	NewAccountsShare float64            `json:"newAccountsShare"` // NewAccounts over Stargazers, 0 to 1
+}
+
+// StarAnomalyDay is the suspicion score of the stars of a day, from 0 to 1
+type StarAnomalyDay struct {
+	Day      JSONDay
+	Stars    int
+	Baseline float64 // average daily stars of the previous weeks
+	Score    float64 // confidence that the stars are bought, higher with the profiles of the stargazers
+}
+
+func (t StarAnomalyDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{t.Day, t.Stars, t.Baseline, t.Score})
+}
+
+// StarAnomalyWindow is a run of consecutive suspicious days
+type StarAnomalyWindow struct {
+	Start       JSONDay  `json:"start"`
+	End         JSONDay  `json:"end"`
+	Stars       int      `json:"stars"`
+	ExcessStars int      `json:"excessStars"` // stars above the baseline, the ones to discount
+	Confidence  float64  `json:"confidence"`  // highest score of the days
+	Reasons     []string `json:"reasons"`
+}
+
+// StarAnomalyReport is the result of the star anomaly detection
+type StarAnomalyReport struct {
+	Days    []StarAnomalyDay    `json:"days"`
+	Windows []StarAnomalyWindow `json:"windows"`
+}
+
+// StargazerOverlap compares the stargazers of two repos
+type StargazerOverlap struct {
+	RepoA        string  `json:"repoA"`
+	RepoB        string  `json:"repoB"`
+	StargazersA  int     `json:"stargazersA"`
+	StargazersB  int     `json:"stargazersB"`
+	Common       int     `json:"common"`
+	Jaccard      float64 `json:"jaccard"`      // Common over the stargazers of any of the two
+	ContainmentA float64 `json:"containmentA"` // share of the stargazers of RepoA who starred RepoB
+	ContainmentB float64 `json:"containmentB"` // share of the stargazers of RepoB who starred RepoA
+}
+
+// RelatedRepo is a repo starred by the stargazers of another one
+type RelatedRepo struct {
+	Repo       string  `json:"repo"`
+	Stargazers int     `json:"stargazers"` // sampled stargazers who also starred Repo
+	Share      float64 `json:"share"`      // Stargazers over the sampled stargazers
+}
+
+// CommitAuthor is the activity of an author on the default branch of a repo
+type CommitAuthor struct {
+	Login       string    `json:"login"` // empty when no GitHub user matches the commits
+	Name        string    `json:"name"`
+	Emails      []string  `json:"emails"`
+	Commits     int       `json:"commits"`
+	FirstCommit time.Time `json:"firstCommit"`
+	LastCommit  time.Time `json:"lastCommit"`
+	ActiveDays  int       `json:"activeDays"`
+	Additions   int       `json:"additions"`
+	Deletions   int       `json:"deletions"`
+	IsBot       bool      `json:"isBot"`
+}
+
+// CommitAuthorsSummary measures how much the commits of a repo depend on few authors
+type CommitAuthorsSummary struct {
+	Authors        int     `json:"authors"`
+	Commits        int     `json:"commits"`
+	BusFactor      int     `json:"busFactor"`      // fewest authors making more than half of the commits
+	Gini           float64 `json:"gini"`           // 0 when all authors made as many commits, close to 1 when one made them all
+	TopAuthorShare float64 `json:"topAuthorShare"` // share of the commits made by the most active author
+}
+
+// IssueResponse is when an issue was first answered and closed
+type IssueResponse struct {
+	Number          int       `json:"number"`
+	Author          string    `json:"author"`
+	CreatedAt       time.Time `json:"createdAt"`
+	FirstResponseAt time.Time `json:"firstResponseAt"` // zero when nobody but the author answered
+	FirstResponder  string    `json:"firstResponder"`
+	ByMaintainer    bool      `json:"byMaintainer"` // FirstResponder is an owner, member or collaborator of the repo
+	ClosedAt        time.Time `json:"closedAt"`     // zero when still open
+}
+
+// DurationDistribution summarizes Count durations, in hours
+type DurationDistribution struct {
+	Count       int     `json:"count"`
+	MedianHours float64 `json:"medianHours"`
+	P90Hours    float64 `json:"p90Hours"`
+}
+
+// IssueResponsivenessMonth summarizes how fast the issues opened in a month were answered and closed
+type IssueResponsivenessMonth struct {
+	Month                   JSONDay              `json:"month"` // first day of the month
+	Opened                  int                  `json:"opened"`
+	Unanswered              int                  `json:"unanswered"`
+	FirstResponse           DurationDistribution `json:"firstResponse"`
+	MaintainerFirstResponse DurationDistribution `json:"maintainerFirstResponse"`
+	CommunityFirstResponse  DurationDistribution `json:"communityFirstResponse"`
+	Close                   DurationDistribution `json:"close"`
+}
+
+// PullRequestLatency is how long a pull request waited for review, approval and merge
+type PullRequestLatency struct {
+	Number        int       `json:"number"`
+	Author        string    `json:"author"`
+	State         string    `json:"state"` // OPEN, CLOSED or MERGED at the clock time
+	Additions     int       `json:"additions"`
+	Deletions     int       `json:"deletions"`
+	CreatedAt     time.Time `json:"createdAt"`
+	FirstReviewAt time.Time `json:"firstReviewAt"` // zero when not reviewed
+	ApprovedAt    time.Time `json:"approvedAt"`    // first approval, zero when not approved
+	MergedAt      time.Time `json:"mergedAt"`      // zero when not merged
+	ClosedAt      time.Time `json:"closedAt"`      // merged or closed, zero when still open
+	ReviewRounds  int       `json:"reviewRounds"`  // reviews requesting changes, plus the last round of reviews
+	Reviewers     []string  `json:"reviewers"`
+}
+
+// PRLatencyMonth summarizes the latency of the pull requests opened in a month
+type PRLatencyMonth struct {
+	Month              JSONDay              `json:"month"` // first day of the month
+	Opened             int                  `json:"opened"`
+	Merged             int                  `json:"merged"`
+	TimeToFirstReview  DurationDistribution `json:"timeToFirstReview"`
+	TimeToApproval     DurationDistribution `json:"timeToApproval"`
+	ApprovalToMerge    DurationDistribution `json:"approvalToMerge"`
+	TimeToMerge        DurationDistribution `json:"timeToMerge"`
+	MedianReviewRounds float64              `json:"medianReviewRounds"` // of the reviewed pull requests
+	MedianChangedLines float64              `json:"medianChangedLines"` // additions plus deletions
+}
+
+// BacklogDay counts the items open at the end of a day, by how long they had been open
+type BacklogDay struct {
+	Day      JSONDay
+	Open     int
+	Under7d  int
+	Under30d int
+	Under90d int
+	Under1y  int
+	Older    int
+}
+
+func (t BacklogDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{t.Day, t.Open, t.Under7d, t.Under30d, t.Under90d, t.Under1y, t.Older})
+}
+
+// StaleItem is an open issue or pull request without activity for a while
+type StaleItem struct {
+	Number        int       `json:"number"`
+	Title         string    `json:"title"`
+	URL           string    `json:"url"`
+	IsPullRequest bool      `json:"isPullRequest"`
+	Author        string    `json:"author"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"` // last activity
+	IdleDays      int       `json:"idleDays"`
+}
+
+// BacklogReport is the history of the open issues and pull requests of a repo and the stale ones
+type BacklogReport struct {
+	Issues       []BacklogDay `json:"issues"`
+	PullRequests []BacklogDay `json:"pullRequests"`
+	Stale        []StaleItem  `json:"stale"`
+}
+
+// ContributorFunnelMonth follows the authors whose first pull request was opened in a month
+type ContributorFunnelMonth struct {
+	Month               JSONDay `json:"month"` // first day of the month
+	FirstTimeAuthors    int     `json:"firstTimeAuthors"`
+	FirstTimeMerged     int     `json:"firstTimeMerged"`     // first-time authors whose first pull request was merged
+	Returned            int     `json:"returned"`            // first-time authors who opened another pull request within 90 days
+	MergedPRs           int     `json:"mergedPRs"`           // pull requests merged in the month
+	ExternalMergedPRs   int     `json:"externalMergedPRs"`   // merged pull requests from authors who are not owners, members or collaborators
+	ExternalMergedShare float64 `json:"externalMergedShare"` // ExternalMergedPRs over MergedPRs, 0 to 1
+}
+
 type StarsHistory struct {
 	AddedLast24H     int
 	AddedLast7d      int